@@ -0,0 +1,35 @@
+// Package jwt provides minimal HS256 JSON Web Token signing for SeaweedFS authentication.
+// 为 SeaweedFS 鉴权提供最小化的 HS256 JWT 签名实现.
+package jwt
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+)
+
+// header is the fixed JOSE header used by SeaweedFS (HS256). SeaweedFS 使用的固定 JOSE 头 (HS256).
+var header = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// SignHS256 encodes claims as a compact JWT signed with HMAC-SHA256.
+// 将 claims 编码为使用 HMAC-SHA256 签名的紧凑 JWT.
+func SignHS256(claims map[string]any, key []byte) (string, error) {
+	if len(key) == 0 {
+		return "", errors.New("jwt: empty signing key")
+	}
+
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := header + "." + base64.RawURLEncoding.EncodeToString(payload)
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(signingInput))
+	sig := base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+
+	return signingInput + "." + sig, nil
+}
@@ -0,0 +1,193 @@
+// Package policy defines safety policies for SeaweedFS operations.
+// This file implements the circuit breaker configured through SafetyPolicy.
+// 为 SeaweedFS 操作定义安全策略, 本文件实现通过 SafetyPolicy 配置的熔断器.
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without contacting the server while the breaker is open.
+// 熔断器打开期间不访问服务端, 直接返回该错误.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// BreakerState is the state of a circuit breaker. 熔断器状态.
+type BreakerState int
+
+const (
+	BreakerClosed   BreakerState = iota // Requests flow normally / 请求正常通过
+	BreakerOpen                         // Requests fail fast / 请求快速失败
+	BreakerHalfOpen                     // A single probe is allowed / 只允许一个探测请求
+)
+
+func (st BreakerState) String() string {
+	switch st {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	default:
+		return fmt.Sprintf("BreakerState(%d)", int(st))
+	}
+}
+
+// Outcome classifies the result of a request for the breaker. 请求结果分类.
+type Outcome int
+
+const (
+	OutcomeSuccess Outcome = iota // Server answered normally / 服务端正常响应
+	OutcomeFailure                // Server unreachable or failing / 服务端不可达或出错
+	OutcomeIgnored                // Not attributable to the server, e.g. cancellation / 不归因于服务端, 如主动取消
+)
+
+// Breaker trips after BreakerFailures consecutive failures or when the failure ratio within
+// BreakerWindow reaches BreakerFailureRate, fails fast for BreakerCooldown, then lets one probe
+// through (half-open) to decide whether to close again.
+// 在连续失败 BreakerFailures 次或 BreakerWindow 内失败率达到 BreakerFailureRate 时熔断, 在 BreakerCooldown
+// 内快速失败, 随后放行一个探测请求 (半开) 以决定是否恢复.
+type Breaker struct {
+	p SafetyPolicy
+
+	mu          sync.Mutex
+	state       BreakerState
+	consecutive int
+	requests    int
+	failures    int
+	windowStart time.Time
+	openedAt    time.Time
+	probing     bool
+}
+
+// NewBreaker creates a breaker from the policy, or returns nil when the policy disables it.
+// A nil *Breaker allows every request.
+// 根据策略创建熔断器, 策略未启用熔断时返回 nil, nil 熔断器放行所有请求.
+func NewBreaker(p SafetyPolicy) *Breaker {
+	if p.BreakerFailures <= 0 && p.BreakerFailureRate <= 0 {
+		return nil
+	}
+	return &Breaker{p: p, windowStart: time.Now()}
+}
+
+// State returns the current state. 返回当前状态.
+func (b *Breaker) State() BreakerState {
+	if b == nil {
+		return BreakerClosed
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// Allow reports whether a request may proceed, returning ErrCircuitOpen if not.
+// Every allowed request must be followed by exactly one Record call.
+// 判断请求是否可以继续, 不可以时返回 ErrCircuitOpen. 每个被放行的请求之后必须调用且仅调用一次 Record.
+func (b *Breaker) Allow() error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	var changed bool
+	switch b.state {
+	case BreakerOpen:
+		if time.Since(b.openedAt) < b.p.BreakerCooldown {
+			b.mu.Unlock()
+			return ErrCircuitOpen
+		}
+		changed = true
+		b.state = BreakerHalfOpen
+		b.probing = true
+	case BreakerHalfOpen:
+		if b.probing {
+			b.mu.Unlock()
+			return ErrCircuitOpen
+		}
+		b.probing = true
+	}
+	b.mu.Unlock()
+
+	if changed {
+		b.notify(BreakerOpen, BreakerHalfOpen)
+	}
+	return nil
+}
+
+// Record reports the outcome of an allowed request. 报告已放行请求的结果.
+func (b *Breaker) Record(o Outcome) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	from := b.state
+
+	switch {
+	case b.state == BreakerHalfOpen:
+		b.probing = false
+		switch o {
+		case OutcomeSuccess:
+			b.reset(BreakerClosed)
+		case OutcomeFailure:
+			b.trip()
+		}
+	case o == OutcomeSuccess:
+		b.advanceWindow()
+		b.requests++
+		b.consecutive = 0
+	case o == OutcomeFailure:
+		b.advanceWindow()
+		b.requests++
+		b.failures++
+		b.consecutive++
+		if b.shouldTrip() {
+			b.trip()
+		}
+	}
+
+	to := b.state
+	b.mu.Unlock()
+
+	if from != to {
+		b.notify(from, to)
+	}
+}
+
+// advanceWindow starts a new counting window when the current one has elapsed. Callers hold mu.
+func (b *Breaker) advanceWindow() {
+	if b.p.BreakerWindow > 0 && time.Since(b.windowStart) >= b.p.BreakerWindow {
+		b.requests, b.failures = 0, 0
+		b.windowStart = time.Now()
+	}
+}
+
+// shouldTrip evaluates both trip conditions. Callers hold mu.
+func (b *Breaker) shouldTrip() bool {
+	if b.p.BreakerFailures > 0 && b.consecutive >= b.p.BreakerFailures {
+		return true
+	}
+	return b.p.BreakerFailureRate > 0 && b.requests >= max(b.p.BreakerMinRequests, 1) &&
+		float64(b.failures)/float64(b.requests) >= b.p.BreakerFailureRate
+}
+
+// trip opens the breaker. Callers hold mu.
+func (b *Breaker) trip() {
+	b.reset(BreakerOpen)
+	b.openedAt = time.Now()
+}
+
+// reset moves to st with fresh counters. Callers hold mu.
+func (b *Breaker) reset(st BreakerState) {
+	b.state = st
+	b.consecutive, b.requests, b.failures = 0, 0, 0
+	b.windowStart = time.Now()
+}
+
+// notify calls the state-change callback outside the lock.
+func (b *Breaker) notify(from, to BreakerState) {
+	if b.p.OnBreakerStateChange != nil {
+		b.p.OnBreakerStateChange(from, to)
+	}
+}
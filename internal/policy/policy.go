@@ -6,37 +6,49 @@ package policy
 import (
 	"context"
 	"errors"
+	"io/fs"
 	"net"
 	"time"
 )
 
 // SafetyPolicy defines safety rules for SeaweedFS operations.
-// It includes maximum upload retries, backoff durations, maximum download chunks, and maximum list pages.
-// 定义 SeaweedFS 操作的安全策略, 包括最大上传重试次数、回退时间、最大下载分块数和最大列表页数.
+// It includes maximum upload retries, backoff durations, maximum download chunks, maximum list pages, and the circuit breaker.
+// 定义 SeaweedFS 操作的安全策略, 包括最大上传重试次数、回退时间、最大下载分块数、最大列表页数和熔断器.
 type SafetyPolicy struct {
 	UploadMaxRetry    int           // Maximum upload retry attempts / 上传最大重试次数
 	BackoffBase       time.Duration // Base backoff duration / 回退基准时间
 	BackoffMax        time.Duration // Maximum backoff duration / 最大回退时间
 	MaxDownloadChunks int           // Maximum number of download chunks / 最大下载分块数
 	MaxListPages      int           // Maximum number of pages in list operations / 最大列表页数
+
+	// Circuit breaker; disabled while both trip conditions are zero / 熔断器, 两个触发条件均为 0 时禁用
+	BreakerFailures      int                         // Consecutive failures that trip the breaker / 触发熔断的连续失败次数
+	BreakerFailureRate   float64                     // Failure ratio within BreakerWindow that trips the breaker (0-1) / 在 BreakerWindow 内触发熔断的失败率 (0-1)
+	BreakerMinRequests   int                         // Requests needed in a window before the rate applies / 失败率生效前窗口内所需的最少请求数
+	BreakerWindow        time.Duration               // Window for the failure rate / 失败率统计窗口
+	BreakerCooldown      time.Duration               // Time spent open before a half-open probe / 熔断后进入半开探测前的冷却时间
+	OnBreakerStateChange func(from, to BreakerState) // Called on every state transition / 每次状态转换时调用
 }
 
 // DefaultSafetyPolicy returns the default safety policy. 返回默认安全策略.
 func DefaultSafetyPolicy() SafetyPolicy {
 	return SafetyPolicy{
-		UploadMaxRetry:    3,
-		BackoffBase:       200 * time.Millisecond,
-		BackoffMax:        5 * time.Second,
-		MaxDownloadChunks: 64,
-		MaxListPages:      1000,
+		UploadMaxRetry:     3,
+		BackoffBase:        200 * time.Millisecond,
+		BackoffMax:         5 * time.Second,
+		MaxDownloadChunks:  64,
+		MaxListPages:       1000,
+		BreakerMinRequests: 20,
+		BreakerWindow:      10 * time.Second,
+		BreakerCooldown:    5 * time.Second,
 	}
 }
 
 // ============ Retry Decision ============
 
 // ShouldRetryUpload determines whether an upload error is retryable.
-// It returns false for context errors, certain HTTP status codes, and true for network errors.
-// 判断上传错误是否可重试. 对于 context 错误、特定 HTTP 状态码返回 false, 对于网络错误返回 true.
+// It returns false for context errors, an open circuit, missing entries, certain HTTP status codes, and true for network errors.
+// 判断上传错误是否可重试. 对于 context 错误、熔断器打开、条目不存在、特定 HTTP 状态码返回 false, 对于网络错误返回 true.
 func ShouldRetryUpload(err error) bool {
 	if err == nil {
 		return false
@@ -48,6 +60,16 @@ func ShouldRetryUpload(err error) bool {
 		return false
 	}
 
+	// An open circuit fails fast / 熔断器打开时快速失败
+	if errors.Is(err, ErrCircuitOpen) {
+		return false
+	}
+
+	// Missing entries do not appear by retrying / 不存在的条目不会因重试而出现
+	if errors.Is(err, fs.ErrNotExist) {
+		return false
+	}
+
 	// HTTP status code (if available) / HTTP 状态码 (如果存在)
 	type httpStatusError interface {
 		StatusCode() int
@@ -0,0 +1,172 @@
+// Package ratelimit provides a token-bucket limiter and rate-limited readers.
+// 提供令牌桶限流器及限速 Reader.
+package ratelimit
+
+import (
+	"context"
+	"io"
+	"math"
+	"sync"
+	"time"
+)
+
+// Limiter is a token bucket refilled at Rate tokens per second up to Burst tokens.
+// A Limiter with a non-positive rate never blocks. It is safe for concurrent use and can be
+// adjusted at runtime with SetRate.
+// 令牌桶限流器, 以每秒 Rate 个令牌的速度补充, 最多容纳 Burst 个. 速率不大于 0 时从不阻塞.
+// 可并发使用, 并可通过 SetRate 在运行时调整.
+type Limiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// New creates a limiter with the given rate and burst, starting with a full bucket.
+// A burst below 1 defaults to one second of rate.
+// 使用给定速率与突发量创建限流器, 初始令牌桶为满, burst 小于 1 时默认为一秒的速率.
+func New(rate float64, burst int) *Limiter {
+	l := &Limiter{}
+	l.SetRate(rate, burst)
+	return l
+}
+
+// SetRate changes the rate and burst. Accumulated tokens are kept up to the new burst;
+// a previously unlimited limiter starts with a full bucket.
+// 修改速率与突发量, 已累积的令牌在新突发量范围内保留, 之前不限速的限流器以满令牌桶开始.
+func (l *Limiter) SetRate(rate float64, burst int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.advance(time.Now())
+	wasUnlimited := l.rate <= 0
+	l.rate = rate
+	l.burst = float64(burst)
+	if l.burst < 1 {
+		l.burst = math.Max(rate, 1)
+	}
+	// A limiter that was unlimited has no debt and starts with a full bucket.
+	if wasUnlimited {
+		l.tokens = l.burst
+	}
+	l.tokens = math.Min(l.tokens, l.burst)
+}
+
+// Rate returns the current rate; non-positive means unlimited. 返回当前速率, 不大于 0 表示不限速.
+func (l *Limiter) Rate() float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.rate
+}
+
+// Burst returns the current burst. 返回当前突发量.
+func (l *Limiter) Burst() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return int(l.burst)
+}
+
+// advance refills tokens for the time elapsed since the last update. Callers hold mu.
+func (l *Limiter) advance(now time.Time) {
+	if !l.last.IsZero() && l.rate > 0 {
+		l.tokens = math.Min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	}
+	l.last = now
+}
+
+// Wait blocks until one token is available. 阻塞直到获得一个令牌.
+func (l *Limiter) Wait(ctx context.Context) error {
+	return l.WaitN(ctx, 1)
+}
+
+// WaitN blocks until n tokens are available or ctx is done. Requests larger than the burst
+// are served in burst-sized portions.
+// 阻塞直到获得 n 个令牌或 ctx 结束, 超过突发量的请求会按突发量分批满足.
+func (l *Limiter) WaitN(ctx context.Context, n int) error {
+	// Track what is left as a float: a fractional burst takes fractional portions.
+	remaining := float64(n)
+	for remaining > 0 {
+		l.mu.Lock()
+		if l.rate <= 0 {
+			l.mu.Unlock()
+			return nil
+		}
+		take := math.Min(remaining, l.burst)
+		now := time.Now()
+		l.advance(now)
+		// Reserve the tokens now (possibly going negative) so concurrent waiters queue fairly.
+		l.tokens -= take
+		var wait time.Duration
+		if l.tokens < 0 {
+			wait = time.Duration(-l.tokens / l.rate * float64(time.Second))
+		}
+		l.mu.Unlock()
+
+		if wait > 0 {
+			t := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				t.Stop()
+				// Return the unused reservation.
+				l.mu.Lock()
+				l.tokens = math.Min(l.burst, l.tokens+take)
+				l.mu.Unlock()
+				return ctx.Err()
+			case <-t.C:
+			}
+		}
+		remaining -= take
+	}
+	return nil
+}
+
+// Reader returns r limited by every non-nil limiter. Reads are capped at the smallest burst so a
+// single Read never needs more tokens than a bucket holds.
+// 返回受所有非 nil 限流器约束的 Reader, 单次读取不超过最小突发量.
+func Reader(ctx context.Context, r io.Reader, limiters ...*Limiter) io.Reader {
+	var ls []*Limiter
+	for _, l := range limiters {
+		if l != nil {
+			ls = append(ls, l)
+		}
+	}
+	if len(ls) == 0 {
+		return r
+	}
+	return &reader{ctx: ctx, r: r, ls: ls}
+}
+
+// ReadCloser is like Reader but keeps the Closer of rc. 与 Reader 相同, 但保留 rc 的 Close.
+func ReadCloser(ctx context.Context, rc io.ReadCloser, limiters ...*Limiter) io.ReadCloser {
+	return &readCloser{Reader: Reader(ctx, rc, limiters...), Closer: rc}
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+type reader struct {
+	ctx context.Context
+	r   io.Reader
+	ls  []*Limiter
+}
+
+func (r *reader) Read(p []byte) (int, error) {
+	max := len(p)
+	for _, l := range r.ls {
+		if b := l.Burst(); l.Rate() > 0 && b < max {
+			max = b
+		}
+	}
+	n, err := r.r.Read(p[:max])
+	if n > 0 {
+		for _, l := range r.ls {
+			if werr := l.WaitN(r.ctx, n); werr != nil {
+				return n, werr
+			}
+		}
+	}
+	return n, err
+}
@@ -0,0 +1,514 @@
+// Package seaweedfs provides a Go client for interacting with SeaweedFS.
+// It includes streaming tar and zip export of remote directories and import of archives into the filer.
+// 提供 SeaweedFS 的 Go 客户端, 包括远程目录的 tar 与 zip 流式导出, 以及将归档导入 filer.
+package seaweedfs
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/GoFurry/seaweedfs-sdk-go/internal/util"
+)
+
+// ArchiveOptions configures ExportTar and ExportZip. 配置 ExportTar 与 ExportZip.
+// Patterns use path.Match syntax and are matched against the path relative to the exported
+// directory; patterns without a "/" also match the base name.
+// 模式使用 path.Match 语法, 与相对于导出目录的路径匹配, 不含 "/" 的模式也与基础名称匹配.
+type ArchiveOptions struct {
+	Include []string // Only files matching one of these are exported; empty exports all / 仅导出匹配其一的文件, 为空时导出全部
+	Exclude []string // Files and directories matching one of these are left out / 匹配其一的文件与目录会被排除
+	Gzip    bool     // Gzip the tar stream (ExportTar only) / 对 tar 流进行 gzip 压缩 (仅 ExportTar)
+}
+
+// matchAny reports whether the relative path rel matches one of patterns. 判断相对路径 rel 是否匹配 patterns 之一.
+func matchAny(patterns []string, rel string) bool {
+	for _, pat := range patterns {
+		if ok, _ := path.Match(pat, rel); ok {
+			return true
+		}
+		if !strings.Contains(pat, "/") {
+			if ok, _ := path.Match(pat, path.Base(rel)); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// archiveEntry is one entry handed to an archive writer. 交给归档写入器的单个条目.
+type archiveEntry struct {
+	name  string // Relative name, directories end with "/" / 相对名称, 目录以 "/" 结尾
+	size  int64
+	mode  os.FileMode
+	mtime time.Time
+	body  io.Reader // nil for directories / 目录为 nil
+}
+
+// ExportTar writes the tree under dir to w as a tar stream, optionally gzipped. Files are streamed
+// from Download one at a time without temp files; names are relative to dir and mtime and mode are
+// taken from the entries. Compressed files are exported decompressed.
+// 将 dir 下的目录树以 tar 流写入 w, 可选 gzip 压缩. 文件逐个从 Download 流式写入, 不使用临时文件;
+// 名称相对于 dir, 修改时间与权限取自条目本身. 压缩的文件以解压后的内容导出.
+func (s *SeaweedFSService) ExportTar(ctx context.Context, dir string, w io.Writer, opts *ArchiveOptions) error {
+	if opts == nil {
+		opts = &ArchiveOptions{}
+	}
+	var gz *gzip.Writer
+	if opts.Gzip {
+		gz = gzip.NewWriter(w)
+		w = gz
+	}
+	tw := tar.NewWriter(w)
+
+	err := s.exportTree(ctx, dir, opts, func(e archiveEntry) error {
+		hdr := &tar.Header{
+			Name:    e.name,
+			Mode:    int64(e.mode),
+			ModTime: e.mtime,
+			Size:    e.size,
+		}
+		if e.body == nil {
+			hdr.Typeflag = tar.TypeDir
+			hdr.Size = 0
+			return tw.WriteHeader(hdr)
+		}
+		hdr.Typeflag = tar.TypeReg
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		_, err := io.Copy(tw, e.body)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if gz != nil {
+		return gz.Close()
+	}
+	return nil
+}
+
+// ExportZip writes the tree under dir to w as a zip stream. Files are streamed from Download one at a
+// time without temp files and deflated; names are relative to dir and mtime and mode are kept.
+// 将 dir 下的目录树以 zip 流写入 w. 文件逐个从 Download 流式写入并以 deflate 压缩, 不使用临时文件;
+// 名称相对于 dir, 保留修改时间与权限.
+func (s *SeaweedFSService) ExportZip(ctx context.Context, dir string, w io.Writer, opts *ArchiveOptions) error {
+	if opts == nil {
+		opts = &ArchiveOptions{}
+	}
+	zw := zip.NewWriter(w)
+
+	err := s.exportTree(ctx, dir, opts, func(e archiveEntry) error {
+		hdr := &zip.FileHeader{
+			Name:     e.name,
+			Modified: e.mtime,
+			Method:   zip.Deflate,
+		}
+		if e.body == nil {
+			hdr.Method = zip.Store
+			hdr.SetMode(e.mode | os.ModeDir)
+			_, err := zw.CreateHeader(hdr)
+			return err
+		}
+		hdr.SetMode(e.mode)
+		fw, err := zw.CreateHeader(hdr)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(fw, e.body)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// exportTree walks dir and calls add for every directory and file that passes the filters.
+// 遍历 dir, 对每个通过过滤的目录与文件调用 add.
+func (s *SeaweedFSService) exportTree(ctx context.Context, dir string, opts *ArchiveOptions, add func(archiveEntry) error) error {
+	dir = util.NormalizePath(dir)
+	prefix := strings.TrimSuffix(dir, "/") + "/"
+
+	return s.Walk(ctx, dir, func(p string, e SeaweedEntry) error {
+		rel := strings.TrimPrefix(p, prefix)
+		if matchAny(opts.Exclude, rel) {
+			if e.IsDir {
+				return SkipDir
+			}
+			return nil
+		}
+		if !e.IsDir && (strings.HasPrefix(e.Name, AtomicTempPrefix) ||
+			len(opts.Include) > 0 && !matchAny(opts.Include, rel)) {
+			return nil
+		}
+
+		stat, err := s.Stat(ctx, p, true)
+		if err != nil {
+			return err
+		}
+		entry := archiveEntry{name: rel, mtime: modTime(stat), mode: os.FileMode(stat.Mode) & os.ModePerm}
+		if e.IsDir {
+			if entry.mode == 0 {
+				entry.mode = 0o755
+			}
+			entry.name += "/"
+			return add(entry)
+		}
+		if entry.mode == 0 {
+			entry.mode = 0o644
+		}
+
+		// The archive header needs the logical size, which differs from the stored one for compressed files.
+		entry.size = stat.Size
+		if isCompressed(stat.Tags) {
+			if entry.size, err = strconv.ParseInt(stat.Tags[compressTagSize], 10, 64); err != nil {
+				return fmt.Errorf("export %s: invalid %s tag: %w", p, compressTagSize, err)
+			}
+		}
+
+		rc, _, err := s.Download(ctx, p, nil)
+		if err != nil {
+			return err
+		}
+		defer rc.Close()
+		entry.body = &exactReader{r: rc, n: entry.size, p: p}
+		return add(entry)
+	})
+}
+
+// exactReader yields exactly n bytes of r and fails if r is shorter or longer, so a file that changes
+// during the export cannot corrupt the archive.
+// 恰好读取 r 的 n 个字节, r 过短或过长时报错, 避免导出期间变化的文件损坏归档.
+type exactReader struct {
+	r io.Reader
+	n int64
+	p string
+}
+
+func (e *exactReader) Read(b []byte) (int, error) {
+	if e.n <= 0 {
+		// Make sure the source has ended as well.
+		var one [1]byte
+		if n, _ := io.ReadFull(e.r, one[:]); n > 0 {
+			return 0, fmt.Errorf("export %s: file grew during export", e.p)
+		}
+		return 0, io.EOF
+	}
+	if int64(len(b)) > e.n {
+		b = b[:e.n]
+	}
+	n, err := e.r.Read(b)
+	e.n -= int64(n)
+	if err == io.EOF && e.n > 0 {
+		return n, fmt.Errorf("export %s: file shrank during export", e.p)
+	}
+	if err == io.EOF {
+		err = nil
+	}
+	return n, err
+}
+
+// Default import limits. 默认导入限制.
+const (
+	defaultImportMaxEntries   = 10000
+	defaultImportMaxTotalSize = 10 << 30
+	importBufferSize          = 8 << 20
+)
+
+// ErrArchiveLimit is returned when an archive exceeds the entry-count or size limits of an import.
+// 归档超出导入的条目数或大小限制时返回.
+var ErrArchiveLimit = errors.New("archive exceeds import limits")
+
+// ImportOptions configures ImportTar and ImportZip. Zero limits select the defaults.
+// 配置 ImportTar 与 ImportZip, 限制为零时使用默认值.
+type ImportOptions struct {
+	Concurrency  int   // Members uploaded in parallel (default 4) / 并行上传的成员数 (默认 4)
+	MaxEntries   int   // Maximum number of members (default 10000) / 最大成员数 (默认 10000)
+	MaxFileSize  int64 // Maximum size of one file (default MaxTotalSize) / 单个文件的最大大小 (默认等于 MaxTotalSize)
+	MaxTotalSize int64 // Maximum uncompressed size of all files (default 10 GiB) / 所有文件解压后的最大总大小 (默认 10 GiB)
+}
+
+// ImportReport summarises an import. 导入结果汇总.
+type ImportReport struct {
+	Dirs   int              // Directories created / 创建的目录数
+	Files  int              // Files uploaded / 上传的文件数
+	Bytes  int64            // Bytes uploaded / 上传的字节数
+	Failed map[string]error // Errors by member name / 按成员名称记录的错误
+}
+
+// importer uploads archive members below dst concurrently and enforces the limits.
+// 将归档成员并发上传到 dst 下并执行限制检查.
+type importer struct {
+	s       *SeaweedFSService
+	dst     string
+	opts    ImportOptions
+	g       *errgroup.Group
+	ctx     context.Context
+	mu      sync.Mutex
+	report  ImportReport
+	entries int
+	total   int64
+}
+
+func (s *SeaweedFSService) newImporter(ctx context.Context, dst string, opts *ImportOptions) *importer {
+	im := &importer{s: s, dst: util.NormalizePath(dst), report: ImportReport{Failed: make(map[string]error)}}
+	if opts != nil {
+		im.opts = *opts
+	}
+	if im.opts.Concurrency <= 0 {
+		im.opts.Concurrency = 4
+	}
+	if im.opts.MaxEntries <= 0 {
+		im.opts.MaxEntries = defaultImportMaxEntries
+	}
+	if im.opts.MaxTotalSize <= 0 {
+		im.opts.MaxTotalSize = defaultImportMaxTotalSize
+	}
+	if im.opts.MaxFileSize <= 0 {
+		im.opts.MaxFileSize = im.opts.MaxTotalSize
+	}
+	im.g, im.ctx = errgroup.WithContext(ctx)
+	im.g.SetLimit(im.opts.Concurrency)
+	return im
+}
+
+// target maps a member name to its destination, rejecting absolute paths and ".." segments.
+// 将成员名称映射到目标路径, 拒绝绝对路径与 ".." 路径段.
+func (im *importer) target(name string) (string, error) {
+	name = strings.ReplaceAll(name, `\`, "/")
+	if strings.HasPrefix(name, "/") || (len(name) > 1 && name[1] == ':') {
+		return "", fmt.Errorf("import %s: absolute path not allowed", name)
+	}
+	for _, seg := range strings.Split(name, "/") {
+		if seg == ".." {
+			return "", fmt.Errorf("import %s: path traversal not allowed", name)
+		}
+	}
+	rel := path.Clean(name)
+	if rel == "." {
+		return im.dst, nil
+	}
+	return JoinPath(im.dst, rel), nil
+}
+
+// admit counts one member of the given size against the limits. Oversized files are a per-entry error;
+// exceeding the entry count or total size aborts the import.
+// 按限制计入一个给定大小的成员. 超大文件为单条目错误, 超出条目数或总大小会终止导入.
+func (im *importer) admit(name string, size int64) (ok bool, err error) {
+	im.mu.Lock()
+	defer im.mu.Unlock()
+	im.entries++
+	if im.entries > im.opts.MaxEntries {
+		return false, fmt.Errorf("import: more than %d entries: %w", im.opts.MaxEntries, ErrArchiveLimit)
+	}
+	if size > im.opts.MaxFileSize {
+		im.report.Failed[name] = fmt.Errorf("import %s: %d bytes exceeds the file size limit: %w", name, size, ErrArchiveLimit)
+		return false, nil
+	}
+	im.total += size
+	if im.total > im.opts.MaxTotalSize {
+		return false, fmt.Errorf("import: more than %d bytes: %w", im.opts.MaxTotalSize, ErrArchiveLimit)
+	}
+	return true, nil
+}
+
+// finish records the outcome of one member. 记录一个成员的结果.
+func (im *importer) finish(name string, dir bool, size int64, err error) {
+	im.mu.Lock()
+	defer im.mu.Unlock()
+	switch {
+	case err != nil:
+		im.report.Failed[name] = err
+	case dir:
+		im.report.Dirs++
+	default:
+		im.report.Files++
+		im.report.Bytes += size
+	}
+}
+
+// mkdir creates the directory member name. 创建目录成员 name.
+func (im *importer) mkdir(name string) {
+	dst, err := im.target(name)
+	if err == nil && dst != im.dst {
+		err = im.s.Mkdir(im.ctx, dst)
+	}
+	im.finish(name, true, 0, err)
+}
+
+// upload uploads one file member in the background; open is called for every attempt.
+// 在后台上传一个文件成员, 每次尝试都会调用 open.
+func (im *importer) upload(name string, size int64, mode os.FileMode, mtime time.Time, open func() (io.ReadCloser, error)) {
+	im.g.Go(func() error {
+		im.finish(name, false, size, im.put(name, size, mode, mtime, open, true))
+		return nil
+	})
+}
+
+// put uploads one file member with its mode and mtime. Replayable members are retried under the
+// service policy; others can only rely on the per-chunk retries of large uploads.
+// 上传一个文件成员并保留其权限与修改时间. 可重放的成员按服务策略重试, 其他成员只能依赖大文件上传的分片重试.
+func (im *importer) put(name string, size int64, mode os.FileMode, mtime time.Time, open func() (io.ReadCloser, error), replayable bool) error {
+	dst, err := im.target(name)
+	if err != nil {
+		return err
+	}
+	uo := &UploadOptions{Mode: mode.Perm()}
+	if !mtime.IsZero() {
+		uo.Tags = FileTags{mtimeTag: mtime.UTC().Format(time.RFC3339Nano)}
+	}
+	upload := func() error {
+		rc, err := open()
+		if err != nil {
+			return err
+		}
+		defer rc.Close()
+		return im.s.Upload(im.ctx, UploadMethodPut, dst, rc, size, uo, nil)
+	}
+	if !replayable {
+		return upload()
+	}
+	return im.s.retry(im.ctx, upload)
+}
+
+// wait waits for pending uploads and returns the report. abortErr, if any, takes precedence.
+// 等待未完成的上传并返回报告, abortErr 不为 nil 时优先返回.
+func (im *importer) wait(abortErr error) (ImportReport, error) {
+	err := im.g.Wait()
+	if abortErr != nil {
+		err = abortErr
+	}
+	return im.report, err
+}
+
+// ImportTar unpacks the tar stream r below dst. Regular files are uploaded concurrently with their mode
+// and mtime (as the Mtime tag) and directories are created; other member types, absolute paths and
+// ".." segments are reported as per-entry errors. Exceeding the entry or total size limits aborts
+// with ErrArchiveLimit. Gzipped streams must be wrapped with gzip.NewReader by the caller.
+// 将 tar 流 r 解包到 dst 下. 普通文件连同权限与修改时间 (以 Mtime 标签保存) 并发上传, 目录会被创建;
+// 其他成员类型、绝对路径与 ".." 路径段记为单条目错误. 超出条目数或总大小限制时以 ErrArchiveLimit 终止.
+// gzip 压缩的流需由调用方用 gzip.NewReader 包装.
+func (s *SeaweedFSService) ImportTar(ctx context.Context, r io.Reader, dst string, opts *ImportOptions) (ImportReport, error) {
+	im := s.newImporter(ctx, dst, opts)
+	tr := tar.NewReader(r)
+	for {
+		if err := im.ctx.Err(); err != nil {
+			return im.wait(err)
+		}
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return im.wait(nil)
+		}
+		if err != nil {
+			return im.wait(err)
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeXGlobalHeader:
+			// PAX global headers carry no member.
+		case tar.TypeDir:
+			if _, err := im.admit(hdr.Name, 0); err != nil {
+				return im.wait(err)
+			}
+			im.mkdir(hdr.Name)
+		case tar.TypeReg:
+			ok, err := im.admit(hdr.Name, hdr.Size)
+			if err != nil {
+				return im.wait(err)
+			}
+			if !ok {
+				continue
+			}
+			mode, mtime := hdr.FileInfo().Mode(), hdr.ModTime
+			if hdr.Size > importBufferSize {
+				// Large members are streamed straight from the archive, which blocks reading.
+				err := im.put(hdr.Name, hdr.Size, mode, mtime, func() (io.ReadCloser, error) {
+					return io.NopCloser(tr), nil
+				}, false)
+				im.finish(hdr.Name, false, hdr.Size, err)
+				continue
+			}
+			// Small members are buffered so the next one can be read while they upload.
+			b, err := io.ReadAll(tr)
+			if err != nil {
+				return im.wait(err)
+			}
+			im.upload(hdr.Name, hdr.Size, mode, mtime, func() (io.ReadCloser, error) {
+				return io.NopCloser(bytes.NewReader(b)), nil
+			})
+		default:
+			if _, err := im.admit(hdr.Name, 0); err != nil {
+				return im.wait(err)
+			}
+			im.finish(hdr.Name, false, 0, fmt.Errorf("import %s: unsupported tar entry type %q", hdr.Name, hdr.Typeflag))
+		}
+	}
+}
+
+// ImportZip unpacks the zip archive r of the given size below dst, uploading members concurrently.
+// Member handling and limits are the same as for ImportTar; sizes are checked before anything is uploaded.
+// 将大小为 size 的 zip 归档 r 解包到 dst 下, 并发上传成员. 成员处理与限制与 ImportTar 相同, 上传前会先检查大小.
+func (s *SeaweedFSService) ImportZip(ctx context.Context, r io.ReaderAt, size int64, dst string, opts *ImportOptions) (ImportReport, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return ImportReport{}, err
+	}
+	im := s.newImporter(ctx, dst, opts)
+
+	// The central directory is known up front, so reject oversized archives before uploading.
+	var files []*zip.File
+	for _, f := range zr.File {
+		mode := f.Mode()
+		switch {
+		case mode.IsDir() || strings.HasSuffix(f.Name, "/"):
+			if _, err := im.admit(f.Name, 0); err != nil {
+				return im.wait(err)
+			}
+			files = append(files, f)
+		case mode.IsRegular():
+			ok, err := im.admit(f.Name, int64(f.UncompressedSize64))
+			if err != nil {
+				return im.wait(err)
+			}
+			if ok {
+				files = append(files, f)
+			}
+		default:
+			if _, err := im.admit(f.Name, 0); err != nil {
+				return im.wait(err)
+			}
+			im.finish(f.Name, false, 0, fmt.Errorf("import %s: unsupported zip entry mode %s", f.Name, mode))
+		}
+	}
+
+	for _, f := range files {
+		if err := im.ctx.Err(); err != nil {
+			return im.wait(err)
+		}
+		if f.Mode().IsDir() || strings.HasSuffix(f.Name, "/") {
+			im.mkdir(f.Name)
+			continue
+		}
+		// archive/zip fails reads beyond the declared size, so the admitted size holds.
+		im.upload(f.Name, int64(f.UncompressedSize64), f.Mode(), f.Modified, f.Open)
+	}
+	return im.wait(nil)
+}
@@ -0,0 +1,151 @@
+package seaweedfs
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"context"
+	"errors"
+	"hash/crc32"
+	"testing"
+)
+
+// tarOf builds a tar archive with the given headers; regular files are filled with zeros.
+func tarOf(t *testing.T, hdrs ...*tar.Header) *bytes.Reader {
+	t.Helper()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, h := range hdrs {
+		if h.Typeflag == 0 {
+			h.Typeflag = tar.TypeReg
+		}
+		if h.Mode == 0 {
+			h.Mode = 0o644
+		}
+		if err := tw.WriteHeader(h); err != nil {
+			t.Fatal(err)
+		}
+		if h.Typeflag == tar.TypeReg {
+			tw.Write(make([]byte, h.Size))
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return bytes.NewReader(buf.Bytes())
+}
+
+func TestImportTarRejectsTraversal(t *testing.T) {
+	f, srv := newFakeFiler(t)
+	s := NewSeaweedFSService(srv.URL)
+
+	r := tarOf(t,
+		&tar.Header{Name: "ok/", Typeflag: tar.TypeDir},
+		&tar.Header{Name: "ok/file", Size: 3},
+		&tar.Header{Name: "../evil", Size: 1},
+		&tar.Header{Name: "ok/../../evil", Size: 1},
+		&tar.Header{Name: "/etc/evil", Size: 1},
+		&tar.Header{Name: `C:\evil`, Size: 1},
+		&tar.Header{Name: "link", Typeflag: tar.TypeSymlink, Linkname: "/etc/passwd"},
+	)
+	report, err := s.ImportTar(context.Background(), r, "/in", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Files != 1 || report.Dirs != 1 || len(report.Failed) != 5 {
+		t.Fatalf("report: %+v", report)
+	}
+	if got, ok := f.file("/in/ok/file"); !ok || len(got) != 3 {
+		t.Fatalf("imported file: %q, %v", got, ok)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for p := range f.m {
+		if p != "/" && p != "/in" && p != "/in/ok" && p != "/in/ok/file" {
+			t.Fatalf("unexpected entry %s", p)
+		}
+	}
+}
+
+func TestImportTarLimits(t *testing.T) {
+	f, srv := newFakeFiler(t)
+	s := NewSeaweedFSService(srv.URL)
+	ctx := context.Background()
+
+	// An oversized file is skipped, the rest is imported.
+	r := tarOf(t, &tar.Header{Name: "big", Size: 100}, &tar.Header{Name: "small", Size: 10})
+	report, err := s.ImportTar(ctx, r, "/a", &ImportOptions{MaxFileSize: 50})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !errors.Is(report.Failed["big"], ErrArchiveLimit) || report.Files != 1 || exists(f, "/a/big") {
+		t.Fatalf("file size limit: %+v", report)
+	}
+
+	r = tarOf(t, &tar.Header{Name: "x", Size: 1}, &tar.Header{Name: "y", Size: 1}, &tar.Header{Name: "z", Size: 1})
+	if _, err := s.ImportTar(ctx, r, "/b", &ImportOptions{MaxEntries: 2}); !errors.Is(err, ErrArchiveLimit) {
+		t.Fatalf("entry limit: got %v, want ErrArchiveLimit", err)
+	}
+	r = tarOf(t, &tar.Header{Name: "x", Size: 60}, &tar.Header{Name: "y", Size: 60})
+	if _, err := s.ImportTar(ctx, r, "/c", &ImportOptions{MaxTotalSize: 100}); !errors.Is(err, ErrArchiveLimit) {
+		t.Fatalf("total size limit: got %v, want ErrArchiveLimit", err)
+	}
+}
+
+func TestImportZipBomb(t *testing.T) {
+	f, srv := newFakeFiler(t)
+	s := NewSeaweedFSService(srv.URL)
+	ctx := context.Background()
+
+	// 1 MiB of zeros compresses to about a kilobyte; the declared size is checked before any upload.
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, name := range []string{"a", "b"} {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(make([]byte, 1<<20))
+	}
+	zw.Close()
+	_, err := s.ImportZip(ctx, bytes.NewReader(buf.Bytes()), int64(buf.Len()), "/z", &ImportOptions{MaxTotalSize: 1<<20 + 1})
+	if !errors.Is(err, ErrArchiveLimit) {
+		t.Fatalf("total size limit: got %v, want ErrArchiveLimit", err)
+	}
+	if exists(f, "/z/a") {
+		t.Fatal("member uploaded before the limit check")
+	}
+
+	// A member that lies about its uncompressed size must not expand past it.
+	var deflated bytes.Buffer
+	fw, _ := flate.NewWriter(&deflated, flate.BestCompression)
+	plain := make([]byte, 1<<20)
+	fw.Write(plain)
+	fw.Close()
+	buf.Reset()
+	zw = zip.NewWriter(&buf)
+	w, err := zw.CreateRaw(&zip.FileHeader{
+		Name:               "liar",
+		Method:             zip.Deflate,
+		CRC32:              crc32.ChecksumIEEE(plain),
+		CompressedSize64:   uint64(deflated.Len()),
+		UncompressedSize64: 10,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write(deflated.Bytes())
+	zw.Close()
+
+	report, err := s.ImportZip(ctx, bytes.NewReader(buf.Bytes()), int64(buf.Len()), "/l", &ImportOptions{MaxTotalSize: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Failed["liar"] == nil {
+		t.Fatalf("lying member imported: %+v", report)
+	}
+	if got, ok := f.file("/l/liar"); ok && len(got) > 10 {
+		t.Fatalf("lying member expanded to %d bytes", len(got))
+	}
+}
@@ -0,0 +1,150 @@
+// Package seaweedfs provides a Go client for interacting with SeaweedFS.
+// It includes atomic write-then-rename uploads and cleanup of stale temp files.
+// 提供 SeaweedFS 的 Go 客户端, 包括先写后重命名的原子上传及残留临时文件清理.
+package seaweedfs
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/GoFurry/seaweedfs-sdk-go/internal/util"
+)
+
+// AtomicTempPrefix prefixes the hidden temp entries written by UploadAtomic. UploadAtomic 写入的隐藏临时文件前缀.
+const AtomicTempPrefix = ".sdk-tmp-"
+
+// cleanupTimeout bounds best-effort cleanup that runs after the caller's context is cancelled.
+const cleanupTimeout = 30 * time.Second
+
+// UploadAtomic uploads to a hidden temp entry in the destination directory, verifies its size and
+// checksum, then moves it onto dst, so readers never observe a partially written file.
+// The temp entry is removed on failure, including context cancellation.
+// 先上传到目标目录中的隐藏临时文件, 校验大小与校验和后再移动到 dst, 读者不会看到写了一半的文件.
+// 失败 (包括 context 取消) 时删除临时文件.
+func (s *SeaweedFSService) UploadAtomic(
+	ctx context.Context,
+	method UploadMethod, // HTTP method / HTTP 方法
+	dst string, // Destination path / 目标路径
+	r io.Reader, // Source reader / 数据源
+	size int64, // Total size / 数据总大小
+	largeThreshold int64, // Threshold for large upload / 大文件阈值
+	chunkSize int64, // Chunk size / 分片大小
+	opts map[string]string, // Optional query parameters / 可选查询参数
+	headers map[string]string, // Optional HTTP headers / 可选 HTTP 头
+	progress ProgressFunc, // Callback for progress / 进度回调
+) (err error) {
+
+	dst = util.NormalizePath(dst)
+	tmp := path.Join(path.Dir(dst), TempFileName(AtomicTempPrefix, "-"+path.Base(dst)))
+
+	defer func() {
+		if err != nil {
+			cctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), cleanupTimeout)
+			defer cancel()
+			_ = s.deleteEntry(cctx, tmp, nil)
+		}
+	}()
+
+	h := md5.New()
+	if err = s.UploadReaderSmart(ctx, method, tmp, io.TeeReader(r, h), size,
+		largeThreshold, chunkSize, opts, headers, progress); err != nil {
+		return err
+	}
+
+	if err = s.verifyUpload(ctx, tmp, size, h.Sum(nil)); err != nil {
+		return err
+	}
+
+	return s.Move(ctx, tmp, dst)
+}
+
+// verifyUpload checks the stored entry against the expected size and MD5.
+// Compressed entries are verified by their recorded original size only.
+// 根据期望大小和 MD5 校验已存储的条目, 压缩条目只校验记录的原始大小.
+func (s *SeaweedFSService) verifyUpload(ctx context.Context, p string, size int64, sum []byte) error {
+	stat, err := s.Stat(ctx, p, true)
+	if err != nil {
+		return err
+	}
+
+	if isCompressed(stat.Tags) {
+		stored, _ := strconv.ParseInt(stat.Tags[compressTagSize], 10, 64)
+		if stored != size {
+			return fmt.Errorf("verify %s: size mismatch: got %d, want %d", p, stored, size)
+		}
+		return nil
+	}
+
+	if stat.Size != size {
+		return fmt.Errorf("verify %s: size mismatch: got %d, want %d", p, stat.Size, size)
+	}
+	// Chunked uploads may have no whole-file MD5 on the filer.
+	if stat.Md5 != "" && !md5Matches(stat.Md5, sum) {
+		return fmt.Errorf("verify %s: md5 mismatch", p)
+	}
+	return nil
+}
+
+// md5Matches compares a filer MD5 (hex or base64) with a raw digest. 比较 filer MD5 (hex 或 base64) 与原始摘要.
+func md5Matches(stored string, sum []byte) bool {
+	return md5Hex(stored) == hex.EncodeToString(sum)
+}
+
+// md5Hex normalizes an MD5 given as hex or base64 to lowercase hex. 将 hex 或 base64 形式的 MD5 统一为小写 hex.
+func md5Hex(v string) string {
+	v = strings.Trim(v, `"`)
+	if b, err := hex.DecodeString(v); err == nil && len(b) == md5.Size {
+		return strings.ToLower(v)
+	}
+	if b, err := base64.StdEncoding.DecodeString(v); err == nil && len(b) == md5.Size {
+		return hex.EncodeToString(b)
+	}
+	return v
+}
+
+// PurgeStaleTemps deletes temp entries left by crashed UploadAtomic calls that are older than olderThan.
+// If recursive is true, subdirectories are scanned as well. It returns the deleted paths and, after a
+// partial purge, the joined errors of the entries that could not be deleted.
+// 删除崩溃的 UploadAtomic 遗留的、早于 olderThan 的临时文件, recursive 为 true 时递归子目录. 返回已删除的路径,
+// 部分失败时同时返回无法删除的条目的合并错误.
+func (s *SeaweedFSService) PurgeStaleTemps(ctx context.Context, dir string, olderThan time.Duration, recursive bool) ([]string, error) {
+	cutoff := time.Now().Add(-olderThan)
+	var stale []string
+
+	err := s.Walk(ctx, dir, func(p string, e SeaweedEntry) error {
+		if e.IsDir {
+			if !recursive {
+				return SkipDir
+			}
+			return nil
+		}
+		if strings.HasPrefix(e.Name, AtomicTempPrefix) && util.ParseSeaweedTime(e.Mtime).Before(cutoff) {
+			stale = append(stale, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var deleted []string
+	var errs []error
+	results := s.deleteBatch(ctx, stale, nil, false, 8, s.deleteEntry)
+	for _, p := range stale {
+		if err := results[p]; err == nil {
+			deleted = append(deleted, p)
+		} else {
+			errs = append(errs, fmt.Errorf("delete %s: %w", p, err))
+		}
+	}
+	return deleted, errors.Join(errs...)
+}
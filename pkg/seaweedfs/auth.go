@@ -0,0 +1,253 @@
+// Package seaweedfs provides a Go client for interacting with SeaweedFS.
+// It includes JWT authentication for filer and volume write requests.
+// 提供 SeaweedFS 的 Go 客户端, 包括 filer 与 volume 写请求的 JWT 鉴权.
+package seaweedfs
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/GoFurry/seaweedfs-sdk-go/internal/jwt"
+)
+
+// TokenRequest describes the request a token is minted for.
+// Fid is set for volume requests, Path for filer requests.
+// 描述需要签发令牌的请求, volume 请求设置 Fid, filer 请求设置 Path.
+type TokenRequest struct {
+	Method string // HTTP method / HTTP 方法
+	Path   string // Filer path / filer 路径
+	Fid    string // Volume file id / volume 文件 ID
+}
+
+// TokenSource provides bearer tokens for authenticated requests.
+// The returned expiry is used to refresh tokens before they expire; a zero expiry means the token never expires.
+// 为需要鉴权的请求提供 Bearer 令牌, 返回的过期时间用于提前刷新, 零值表示永不过期.
+type TokenSource interface {
+	Token(ctx context.Context, req TokenRequest) (token string, expiry time.Time, err error)
+}
+
+// TokenSourceFunc adapts an ordinary function to the TokenSource interface.
+// 将普通函数适配为 TokenSource 接口.
+type TokenSourceFunc func(ctx context.Context, req TokenRequest) (string, time.Time, error)
+
+// Token implements TokenSource. 实现 TokenSource 接口.
+func (f TokenSourceFunc) Token(ctx context.Context, req TokenRequest) (string, time.Time, error) {
+	return f(ctx, req)
+}
+
+// StaticToken returns a TokenSource that always yields the same token.
+// 返回始终提供同一令牌的 TokenSource.
+func StaticToken(token string) TokenSource {
+	return TokenSourceFunc(func(context.Context, TokenRequest) (string, time.Time, error) {
+		return token, time.Time{}, nil
+	})
+}
+
+// JWTSigner mints HS256 tokens compatible with SeaweedFS jwt.signing.key and jwt.filer_signing.key.
+// 签发与 SeaweedFS jwt.signing.key 和 jwt.filer_signing.key 兼容的 HS256 令牌.
+type JWTSigner struct {
+	Key []byte        // Signing key / 签名密钥
+	TTL time.Duration // Token lifetime / 令牌有效期
+}
+
+// NewJWTSigner creates a JWTSigner; ttl defaults to 10 seconds like SeaweedFS expires_after_seconds.
+// 创建 JWTSigner, ttl 默认为 10 秒, 与 SeaweedFS expires_after_seconds 默认值一致.
+func NewJWTSigner(key []byte, ttl time.Duration) *JWTSigner {
+	if ttl <= 0 {
+		ttl = 10 * time.Second
+	}
+	return &JWTSigner{Key: key, TTL: ttl}
+}
+
+// Token implements TokenSource. The fid claim is set for volume requests and the path claim for filer requests.
+// 实现 TokenSource, volume 请求携带 fid 声明, filer 请求携带 path 声明.
+func (j *JWTSigner) Token(_ context.Context, req TokenRequest) (string, time.Time, error) {
+	expiry := time.Now().Add(j.TTL)
+	claims := map[string]any{
+		"exp": expiry.Unix(),
+	}
+	if req.Fid != "" {
+		claims["fid"] = req.Fid
+	} else if req.Path != "" {
+		claims["path"] = req.Path
+	}
+
+	token, err := jwt.SignHS256(claims, j.Key)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return token, expiry, nil
+}
+
+// tokenCache wraps a TokenSource and reuses tokens until they are close to expiry.
+// 包装 TokenSource, 在令牌临近过期前复用.
+type tokenCache struct {
+	src    TokenSource
+	skew   time.Duration
+	mu     sync.Mutex
+	tokens map[TokenRequest]cachedToken
+}
+
+type cachedToken struct {
+	token  string
+	expiry time.Time
+}
+
+func newTokenCache(src TokenSource, skew time.Duration) *tokenCache {
+	return &tokenCache{
+		src:    src,
+		skew:   skew,
+		tokens: make(map[TokenRequest]cachedToken),
+	}
+}
+
+// Token implements TokenSource. 实现 TokenSource 接口.
+func (c *tokenCache) Token(ctx context.Context, req TokenRequest) (string, time.Time, error) {
+	now := time.Now()
+
+	c.mu.Lock()
+	if t, ok := c.tokens[req]; ok && (t.expiry.IsZero() || now.Add(c.skew).Before(t.expiry)) {
+		c.mu.Unlock()
+		return t.token, t.expiry, nil
+	}
+	c.mu.Unlock()
+
+	token, expiry, err := c.src.Token(ctx, req)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	c.mu.Lock()
+	// Drop expired entries so the cache does not grow with every distinct path.
+	for k, t := range c.tokens {
+		if !t.expiry.IsZero() && !now.Before(t.expiry) {
+			delete(c.tokens, k)
+		}
+	}
+	c.tokens[req] = cachedToken{token: token, expiry: expiry}
+	c.mu.Unlock()
+
+	return token, expiry, nil
+}
+
+// WithTokenSource sets the TokenSource used to authorize write requests.
+// Tokens are cached per request scope and refreshed shortly before they expire.
+// Volume requests use it too unless WithVolumeTokenSource is set.
+// 设置写请求使用的 TokenSource, 令牌按请求范围缓存并在过期前刷新.
+// 未设置 WithVolumeTokenSource 时 volume 请求也使用它.
+func WithTokenSource(ts TokenSource) Option {
+	return func(s *SeaweedFSService) {
+		if ts != nil {
+			s.tokens = newTokenCache(ts, 2*time.Second)
+		}
+	}
+}
+
+// WithVolumeTokenSource sets the TokenSource used to authorize volume server write requests.
+// 设置 volume 服务器写请求使用的 TokenSource.
+func WithVolumeTokenSource(ts TokenSource) Option {
+	return func(s *SeaweedFSService) {
+		if ts != nil {
+			s.volumeTokens = newTokenCache(ts, 2*time.Second)
+		}
+	}
+}
+
+// WithJWTSigningKey signs every filer write request with a JWT derived from key (jwt.filer_signing.key).
+// Volume requests are signed with the same key unless WithVolumeJWTSigningKey is set, which is
+// required when the cluster uses a different jwt.signing.key.
+// 使用 key (jwt.filer_signing.key) 为每个 filer 写请求签发 JWT. 未设置 WithVolumeJWTSigningKey 时
+// volume 请求也使用该密钥签名, 集群的 jwt.signing.key 不同时必须设置它.
+func WithJWTSigningKey(key []byte, ttl time.Duration) Option {
+	return func(s *SeaweedFSService) {
+		if len(key) > 0 {
+			s.tokens = NewJWTSigner(key, ttl)
+		}
+	}
+}
+
+// WithVolumeJWTSigningKey signs every volume server write request with a JWT derived from key (jwt.signing.key).
+// 使用 key (jwt.signing.key) 为每个 volume 服务器写请求签发 JWT.
+func WithVolumeJWTSigningKey(key []byte, ttl time.Duration) Option {
+	return func(s *SeaweedFSService) {
+		if len(key) > 0 {
+			s.volumeTokens = NewJWTSigner(key, ttl)
+		}
+	}
+}
+
+// authorize attaches a bearer token to write requests (upload, delete, move, tag).
+// Master requests are never signed.
+// 为写请求 (上传、删除、移动、标签) 附加 Bearer 令牌, master 请求不签名.
+func (s *SeaweedFSService) authorize(req *http.Request) error {
+	if req.Header.Get("Authorization") != "" {
+		return nil
+	}
+	if req.Method == http.MethodGet || req.Method == http.MethodHead {
+		return nil
+	}
+
+	// Requests that target neither the filer nor the master are volume requests keyed by fid.
+	tr := TokenRequest{Method: req.Method}
+	ts := s.tokens
+	switch s.target(req.URL) {
+	case targetFiler:
+		tr.Path = req.URL.Path
+	case targetMaster:
+		return nil
+	default:
+		tr.Fid = strings.TrimPrefix(req.URL.Path, "/")
+		if s.volumeTokens != nil {
+			ts = s.volumeTokens
+		}
+	}
+	if ts == nil {
+		return nil
+	}
+
+	token, _, err := ts.Token(req.Context(), tr)
+	if err != nil {
+		return err
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return nil
+}
+
+// requestTarget is the kind of server a request is sent to. 请求发送到的服务器类型.
+type requestTarget int
+
+const (
+	targetFiler requestTarget = iota
+	targetMaster
+	targetVolume
+)
+
+// target classifies u by comparing its scheme, host and path prefix with the filer and master endpoints.
+// 通过比较协议、主机与路径前缀, 判断 u 指向 filer、master 还是 volume 服务器.
+func (s *SeaweedFSService) target(u *url.URL) requestTarget {
+	switch {
+	case sameEndpoint(u, s.FilerEndpoint):
+		return targetFiler
+	case s.masterEndpoint != "" && sameEndpoint(u, s.masterEndpoint):
+		return targetMaster
+	default:
+		return targetVolume
+	}
+}
+
+// sameEndpoint reports whether u has the scheme and host of endpoint and lies below its path.
+// 判断 u 是否与 endpoint 的协议、主机相同且位于其路径之下.
+func sameEndpoint(u *url.URL, endpoint string) bool {
+	e, err := url.Parse(endpoint)
+	if err != nil || !strings.EqualFold(u.Scheme, e.Scheme) || !strings.EqualFold(u.Host, e.Host) {
+		return false
+	}
+	prefix := strings.TrimRight(e.Path, "/")
+	return prefix == "" || u.Path == prefix || strings.HasPrefix(u.Path, prefix+"/")
+}
@@ -0,0 +1,70 @@
+// Package seaweedfs provides a Go client for interacting with SeaweedFS.
+// It includes the circuit breaker that guards requests to the filer.
+// 提供 SeaweedFS 的 Go 客户端, 包括保护 filer 请求的熔断器.
+package seaweedfs
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/GoFurry/seaweedfs-sdk-go/internal/policy"
+)
+
+// ErrCircuitOpen is returned without contacting the filer while the circuit breaker is open.
+// 熔断器打开期间不访问 filer, 直接返回该错误.
+var ErrCircuitOpen = policy.ErrCircuitOpen
+
+// BreakerState is the state of the circuit breaker. 熔断器状态.
+type BreakerState = policy.BreakerState
+
+// Circuit breaker states. 熔断器状态.
+const (
+	BreakerClosed   = policy.BreakerClosed
+	BreakerOpen     = policy.BreakerOpen
+	BreakerHalfOpen = policy.BreakerHalfOpen
+)
+
+// WithCircuitBreaker enables the circuit breaker for filer requests: it trips after failures consecutive failures or when
+// the failure ratio reaches failureRate (0 disables either condition), and half-opens after cooldown.
+// The remaining settings come from the safety policy.
+// 为 filer 请求启用熔断器: 连续失败 failures 次或失败率达到 failureRate 时熔断 (0 表示禁用对应条件), 冷却 cooldown 后半开.
+// 其余设置来自安全策略.
+func WithCircuitBreaker(failures int, failureRate float64, cooldown time.Duration) Option {
+	return func(s *SeaweedFSService) {
+		s.policy.BreakerFailures = failures
+		s.policy.BreakerFailureRate = failureRate
+		if cooldown > 0 {
+			s.policy.BreakerCooldown = cooldown
+		}
+	}
+}
+
+// WithBreakerStateChange sets a callback invoked on every circuit breaker state transition.
+// 设置熔断器每次状态转换时调用的回调.
+func WithBreakerStateChange(fn func(from, to BreakerState)) Option {
+	return func(s *SeaweedFSService) {
+		s.policy.OnBreakerStateChange = fn
+	}
+}
+
+// BreakerState returns the current circuit breaker state; BreakerClosed when it is disabled.
+// 返回熔断器当前状态, 未启用时返回 BreakerClosed.
+func (s *SeaweedFSService) BreakerState() BreakerState {
+	return s.breaker.State()
+}
+
+// outcome classifies a round trip for the breaker: transport errors, 5xx and 429 count as failures,
+// while errors caused by the caller's context are ignored.
+// 为熔断器分类一次请求: 传输错误、5xx 与 429 计为失败, 调用方 context 引起的错误被忽略.
+func outcome(req *http.Request, resp *http.Response, err error) policy.Outcome {
+	switch {
+	case err != nil && req.Context().Err() != nil:
+		return policy.OutcomeIgnored
+	case err != nil:
+		return policy.OutcomeFailure
+	case resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests:
+		return policy.OutcomeFailure
+	default:
+		return policy.OutcomeSuccess
+	}
+}
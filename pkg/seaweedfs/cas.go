@@ -0,0 +1,421 @@
+// Package seaweedfs provides a Go client for interacting with SeaweedFS.
+// It includes a content-addressed, deduplicating store built on top of the filer.
+// 提供 SeaweedFS 的 Go 客户端, 包括基于 filer 的内容寻址去重存储.
+package seaweedfs
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/GoFurry/seaweedfs-sdk-go/internal/util"
+)
+
+// CAS tags: pointers carry the blob hash, blobs carry a best-effort reference count.
+// CAS 标签: 指针记录 blob 哈希, blob 记录尽力而为的引用计数.
+const (
+	casTagHash    = "Cas-Hash"
+	casTagRefs    = "Cas-Refs"
+	casTagTouched = "Cas-Touched"
+)
+
+// CASStore stores each distinct content once under a hash-sharded directory and maps logical names to blobs.
+//
+// Layout under Root:
+//
+//	blobs/<h[0:2]>/<h[2:4]>/<sha256>  content blobs
+//	names/<logical name>              pointer entries holding the blob hash
+//	tmp/                              staging area for in-flight uploads
+//
+// 将相同内容只存储一次 (按哈希分片目录), 并把逻辑名称映射到 blob.
+type CASStore struct {
+	s              *SeaweedFSService
+	Root           string // Store root directory / 存储根目录
+	LargeThreshold int64  // Threshold for chunked upload / 分片上传阈值
+	ChunkSize      int64  // Chunk size for large blobs / 大 blob 分片大小
+}
+
+// CASGCResult reports the outcome of a garbage-collection sweep. 垃圾回收结果.
+type CASGCResult struct {
+	Names      int              // Pointer entries scanned / 扫描的指针数
+	Blobs      int              // Blobs scanned / 扫描的 blob 数
+	Referenced int              // Blobs still referenced / 仍被引用的 blob 数
+	Deleted    []string         // Deleted (or, in dry-run, deletable) blob hashes / 已删除 (或演练模式下可删除) 的 blob 哈希
+	Failed     map[string]error // Deletion errors by name / 按名称记录的删除错误
+}
+
+// NewCASStore creates a content-addressed store rooted at root. 创建以 root 为根的内容寻址存储.
+func NewCASStore(s *SeaweedFSService, root string) *CASStore {
+	return &CASStore{
+		s:              s,
+		Root:           util.NormalizePath(root),
+		LargeThreshold: 32 << 20,
+		ChunkSize:      8 << 20,
+	}
+}
+
+// BlobPath returns the filer path of the blob with the given SHA-256 hash, which must be 64 lowercase
+// hex characters. 返回给定 SHA-256 哈希 blob 的 filer 路径, 哈希必须为 64 个小写十六进制字符.
+func (c *CASStore) BlobPath(hash string) (string, error) {
+	if !validCASHash(hash) {
+		return "", fmt.Errorf("invalid cas hash %q", hash)
+	}
+	return JoinPath(c.Root, "blobs", hash[0:2], hash[2:4], hash), nil
+}
+
+// validCASHash reports whether hash is a lowercase hex SHA-256 digest. 判断 hash 是否为小写十六进制 SHA-256 摘要.
+func validCASHash(hash string) bool {
+	if len(hash) != sha256.Size*2 {
+		return false
+	}
+	for _, r := range hash {
+		if (r < '0' || r > '9') && (r < 'a' || r > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// namePath validates a logical name and returns its pointer path. 校验逻辑名称并返回其指针路径.
+func (c *CASStore) namePath(name string) (string, error) {
+	if name == "" || strings.HasPrefix(name, "/") || strings.HasSuffix(name, "/") {
+		return "", fmt.Errorf("invalid cas name %q", name)
+	}
+	for _, seg := range strings.Split(name, "/") {
+		if seg == "" || seg == "." || seg == ".." {
+			return "", fmt.Errorf("invalid cas name %q", name)
+		}
+	}
+	return JoinPath(c.Root, "names", name), nil
+}
+
+// Put stores content under a logical name and returns its SHA-256 hash.
+// The content is staged in a temp entry while hashing, then moved into place unless an identical blob exists.
+// 以逻辑名称存储内容并返回其 SHA-256 哈希. 上传时先暂存并计算哈希, 若已存在相同 blob 则丢弃暂存文件.
+func (c *CASStore) Put(ctx context.Context, name string, r io.Reader, size int64, headers map[string]string) (string, error) {
+	if _, err := c.namePath(name); err != nil {
+		return "", err
+	}
+	tmp := JoinPath(c.Root, "tmp", TempFileName("", ".blob"))
+
+	h := sha256.New()
+	err := c.s.UploadReaderSmart(ctx, UploadMethodPut, tmp, io.TeeReader(r, h), size,
+		c.LargeThreshold, c.ChunkSize, nil, headers, nil)
+	if err != nil {
+		_ = c.s.deleteEntry(context.WithoutCancel(ctx), tmp, nil)
+		return "", err
+	}
+	hash := hex.EncodeToString(h.Sum(nil))
+	blob, _ := c.BlobPath(hash)
+
+	linked, err := c.linkExisting(ctx, name, hash)
+	if err == nil && linked {
+		// The staged copy is redundant; GC removes it if this delete fails.
+		_ = c.s.deleteEntry(ctx, tmp, nil)
+		return hash, nil
+	}
+	if err == nil {
+		err = c.s.Move(ctx, tmp, blob)
+	}
+	if err != nil {
+		_ = c.s.deleteEntry(context.WithoutCancel(ctx), tmp, nil)
+		return "", err
+	}
+
+	return hash, c.link(ctx, name, hash)
+}
+
+// PutFile stores a local file under a logical name. The hash is computed locally first,
+// so content that is already stored is never uploaded again.
+// 以逻辑名称存储本地文件, 先在本地计算哈希, 已存在的内容不会重复上传.
+func (c *CASStore) PutFile(ctx context.Context, name, localPath string) (string, error) {
+	if _, err := c.namePath(name); err != nil {
+		return "", err
+	}
+	hash, err := SHA256File(localPath)
+	if err != nil {
+		return "", err
+	}
+	blob, _ := c.BlobPath(hash)
+
+	linked, err := c.linkExisting(ctx, name, hash)
+	if err != nil {
+		return "", err
+	}
+	if linked {
+		return hash, nil
+	}
+
+	tmp := JoinPath(c.Root, "tmp", TempFileName("", ".blob"))
+	err = c.s.UploadLocalFile(ctx, UploadMethodPut, tmp, localPath, c.LargeThreshold, c.ChunkSize, nil, nil, nil)
+	if err == nil {
+		err = c.s.Move(ctx, tmp, blob)
+	}
+	if err != nil {
+		_ = c.s.deleteEntry(context.WithoutCancel(ctx), tmp, nil)
+		return "", err
+	}
+
+	return hash, c.link(ctx, name, hash)
+}
+
+// linkExisting links name to an already stored blob. The blob is touched first so a concurrent GC
+// sweep keeps it, and checked again after linking in case the sweep removed it in between; false
+// means the blob is missing and the caller must store it.
+// 将 name 链接到已存储的 blob. 先更新 blob 的访问标记使并发 GC 保留它, 链接后再次检查以防其间被清理;
+// 返回 false 表示 blob 不存在, 调用方需要存储它.
+func (c *CASStore) linkExisting(ctx context.Context, name, hash string) (bool, error) {
+	blob, _ := c.BlobPath(hash)
+	exists, err := c.s.Exists(ctx, blob)
+	if err != nil || !exists {
+		return false, err
+	}
+	// Tag updates keep the entry's mtime, so the touch time is recorded as a tag.
+	if err := c.s.SetTags(ctx, blob, FileTags{casTagTouched: time.Now().UTC().Format(time.RFC3339Nano)}); err != nil {
+		// The blob vanished since the check; anything else is a real failure.
+		var se *StatusError
+		if errors.As(err, &se) && se.Code == http.StatusNotFound {
+			return false, nil
+		}
+		return false, err
+	}
+	if err := c.link(ctx, name, hash); err != nil {
+		return false, err
+	}
+	return c.s.Exists(ctx, blob)
+}
+
+// link points name at hash and adjusts reference counts of the new and previous blobs.
+// 将 name 指向 hash, 并调整新旧 blob 的引用计数.
+func (c *CASStore) link(ctx context.Context, name, hash string) error {
+	np, err := c.namePath(name)
+	if err != nil {
+		return err
+	}
+	prev, err := c.Resolve(ctx, name)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if prev == hash {
+		return nil
+	}
+
+	err = c.s.UploadWithOptions(ctx, UploadMethodPut, np, strings.NewReader(hash), nil,
+		map[string]string{
+			"Content-Type":          "text/plain",
+			"Seaweed-" + casTagHash: hash,
+		}, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := c.addRef(ctx, hash, 1); err != nil {
+		return err
+	}
+	if prev != "" {
+		return c.addRef(ctx, prev, -1)
+	}
+	return nil
+}
+
+// Resolve returns the blob hash a logical name points to. A pointer that does not hold a valid
+// hash is reported as an error. 返回逻辑名称指向的 blob 哈希, 指针内容不是有效哈希时返回错误.
+func (c *CASStore) Resolve(ctx context.Context, name string) (string, error) {
+	np, err := c.namePath(name)
+	if err != nil {
+		return "", err
+	}
+	tags, err := c.s.GetTags(ctx, np)
+	if err != nil {
+		return "", err
+	}
+	hash := tags[casTagHash]
+
+	// Fall back to the pointer body when tags are unavailable.
+	if hash == "" {
+		rc, _, err := c.s.Download(ctx, np, nil)
+		if err != nil {
+			return "", err
+		}
+		defer rc.Close()
+		b, err := io.ReadAll(io.LimitReader(rc, 128))
+		if err != nil {
+			return "", err
+		}
+		hash = strings.TrimSpace(string(b))
+	}
+
+	if !validCASHash(hash) {
+		return "", fmt.Errorf("cas pointer %s: invalid hash %q", name, hash)
+	}
+	return hash, nil
+}
+
+// Get opens the content stored under a logical name. 打开逻辑名称对应的内容.
+func (c *CASStore) Get(ctx context.Context, name string) (io.ReadCloser, http.Header, error) {
+	hash, err := c.Resolve(ctx, name)
+	if err != nil {
+		return nil, nil, err
+	}
+	blob, _ := c.BlobPath(hash)
+	return c.s.Download(ctx, blob, nil)
+}
+
+// Delete removes a logical name and decrements the blob's reference count.
+// The blob itself is only removed by GC.
+// 删除逻辑名称并减少 blob 引用计数, blob 本身只由 GC 删除.
+func (c *CASStore) Delete(ctx context.Context, name string) error {
+	hash, err := c.Resolve(ctx, name)
+	if err != nil {
+		return err
+	}
+	np, _ := c.namePath(name)
+	if err := c.s.deleteEntry(ctx, np, nil); err != nil {
+		return err
+	}
+	return c.addRef(ctx, hash, -1)
+}
+
+// Refs returns the recorded reference count of a blob. 返回 blob 记录的引用计数.
+func (c *CASStore) Refs(ctx context.Context, hash string) (int, error) {
+	blob, err := c.BlobPath(hash)
+	if err != nil {
+		return 0, err
+	}
+	tags, err := c.s.GetTags(ctx, blob)
+	if err != nil {
+		return 0, err
+	}
+	n, _ := strconv.Atoi(tags[casTagRefs])
+	return n, nil
+}
+
+// addRef adjusts the reference count tag. Concurrent writers may race; GC does not rely on it.
+// 调整引用计数标签, 并发写入可能产生竞争, GC 不依赖该计数.
+func (c *CASStore) addRef(ctx context.Context, hash string, delta int) error {
+	n, err := c.Refs(ctx, hash)
+	if err != nil {
+		return err
+	}
+	n += delta
+	if n < 0 {
+		n = 0
+	}
+	blob, _ := c.BlobPath(hash)
+	return c.s.SetTags(ctx, blob, FileTags{casTagRefs: strconv.Itoa(n)})
+}
+
+// GC deletes blobs no pointer refers to. It marks every hash referenced from names/ and sweeps blobs/.
+// Blobs and staging files younger than grace, and blobs a Put linked to within grace, are kept to
+// protect in-flight Puts.
+// When dryRun is true nothing is deleted and Deleted lists the blobs that would be removed.
+// 删除未被任何指针引用的 blob: 先标记 names/ 下引用的哈希, 再清理 blobs/.
+// 存在时间短于 grace 的 blob 与暂存文件, 以及 grace 内被 Put 链接过的 blob 会被保留以保护进行中的 Put,
+// dryRun 为 true 时只报告不删除.
+func (c *CASStore) GC(ctx context.Context, grace time.Duration, dryRun bool) (CASGCResult, error) {
+	result := CASGCResult{Failed: make(map[string]error)}
+	referenced := make(map[string]bool)
+
+	// Mark phase.
+	err := c.s.Walk(ctx, JoinPath(c.Root, "names"), func(p string, e SeaweedEntry) error {
+		if e.IsDir {
+			return nil
+		}
+		result.Names++
+		hash, err := c.Resolve(ctx, strings.TrimPrefix(p, JoinPath(c.Root, "names")+"/"))
+		if err != nil {
+			return fmt.Errorf("resolve %s: %w", p, err)
+		}
+		referenced[hash] = true
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return result, err
+	}
+
+	// Sweep phase.
+	cutoff := time.Now().Add(-grace)
+	var garbage []string
+	err = c.s.Walk(ctx, JoinPath(c.Root, "blobs"), func(p string, e SeaweedEntry) error {
+		if e.IsDir {
+			return nil
+		}
+		result.Blobs++
+		if referenced[e.Name] {
+			result.Referenced++
+			return nil
+		}
+		if util.ParseSeaweedTime(e.Mtime).After(cutoff) {
+			return nil
+		}
+		result.Deleted = append(result.Deleted, e.Name)
+		garbage = append(garbage, p)
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return result, err
+	}
+
+	// Stale staging files are left behind by interrupted Puts.
+	err = c.s.Walk(ctx, JoinPath(c.Root, "tmp"), func(p string, e SeaweedEntry) error {
+		if !e.IsDir && util.ParseSeaweedTime(e.Mtime).Before(cutoff) {
+			garbage = append(garbage, p)
+		}
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return result, err
+	}
+
+	if dryRun {
+		return result, nil
+	}
+
+	// A Put may have linked a marked-as-garbage blob since the mark phase; re-check its touch time
+	// right before deleting it.
+	blobs := JoinPath(c.Root, "blobs") + "/"
+	kept := make(map[string]bool)
+	var mu sync.Mutex
+	del := func(ctx context.Context, p string, extra map[string]string) error {
+		if strings.HasPrefix(p, blobs) {
+			tags, err := c.s.GetTags(ctx, p)
+			if err != nil {
+				return err
+			}
+			if t, err := time.Parse(time.RFC3339Nano, tags[casTagTouched]); err == nil && t.After(cutoff) {
+				mu.Lock()
+				kept[path.Base(p)] = true
+				mu.Unlock()
+				return nil
+			}
+		}
+		return c.s.deleteEntry(ctx, p, extra)
+	}
+	for p, err := range c.s.deleteBatch(ctx, garbage, nil, false, 8, del) {
+		if err != nil {
+			result.Failed[path.Base(p)] = err
+		}
+	}
+
+	deleted := result.Deleted[:0]
+	for _, h := range result.Deleted {
+		switch {
+		case kept[h]:
+			result.Referenced++
+		case result.Failed[h] == nil:
+			deleted = append(deleted, h)
+		}
+	}
+	result.Deleted = deleted
+	return result, nil
+}
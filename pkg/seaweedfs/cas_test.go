@@ -0,0 +1,220 @@
+package seaweedfs
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// backdate sets the mtime of the fake entry at p.
+func (f *fakeFiler) backdate(p string, d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.m[p].mtime = time.Now().Add(-d)
+}
+
+func TestCASDedup(t *testing.T) {
+	f, srv := newFakeFiler(t)
+	c := NewCASStore(NewSeaweedFSService(srv.URL), "/cas")
+	ctx := context.Background()
+
+	h1, err := c.Put(ctx, "a/x", strings.NewReader("hello"), 5, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h2, err := c.Put(ctx, "b", strings.NewReader("hello"), 5, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h1 != h2 {
+		t.Fatalf("same content, different hashes %s and %s", h1, h2)
+	}
+	if n, err := c.Refs(ctx, h1); err != nil || n != 2 {
+		t.Fatalf("refs: got %d, %v, want 2", n, err)
+	}
+	blob, _ := c.BlobPath(h1)
+	if got, ok := f.file(blob); !ok || string(got) != "hello" {
+		t.Fatalf("blob: got %q, %v", got, ok)
+	}
+
+	rc, _, err := c.Get(ctx, "a/x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := readAllClose(t, rc); string(got) != "hello" {
+		t.Fatalf("get: got %q", got)
+	}
+
+	// Repointing and deleting names release their references.
+	if _, err := c.Put(ctx, "b", strings.NewReader("world"), 5, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Delete(ctx, "a/x"); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := c.Refs(ctx, h1); err != nil || n != 0 {
+		t.Fatalf("refs after release: got %d, %v, want 0", n, err)
+	}
+}
+
+func TestCASRejectsInvalidInput(t *testing.T) {
+	f, srv := newFakeFiler(t)
+	c := NewCASStore(NewSeaweedFSService(srv.URL), "/cas")
+	ctx := context.Background()
+
+	if _, err := c.BlobPath("ab"); err == nil {
+		t.Fatal("short hash accepted")
+	}
+	for _, name := range []string{"../x", "a/../../x", "/abs", ""} {
+		if _, err := c.Put(ctx, name, strings.NewReader("a"), 1, nil); err == nil {
+			t.Fatalf("name %q accepted", name)
+		}
+	}
+
+	// A pointer that does not hold a hash must not be followed.
+	f.mu.Lock()
+	f.m["/cas/names/bad"] = &fakeEntry{data: []byte("../../etc"), tags: map[string]string{}}
+	f.mu.Unlock()
+	if _, _, err := c.Get(ctx, "bad"); err == nil {
+		t.Fatal("invalid pointer followed")
+	}
+}
+
+func TestCASGC(t *testing.T) {
+	f, srv := newFakeFiler(t)
+	c := NewCASStore(NewSeaweedFSService(srv.URL), "/cas")
+	ctx := context.Background()
+
+	live, _ := c.Put(ctx, "live", strings.NewReader("live"), 4, nil)
+	dead, _ := c.Put(ctx, "dead", strings.NewReader("dead"), 4, nil)
+	young, _ := c.Put(ctx, "young", strings.NewReader("young"), 5, nil)
+	for _, name := range []string{"dead", "young"} {
+		if err := c.Delete(ctx, name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, h := range []string{live, dead} {
+		blob, _ := c.BlobPath(h)
+		f.backdate(blob, time.Hour)
+	}
+
+	res, err := c.GC(ctx, time.Minute, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Deleted) != 1 || res.Deleted[0] != dead {
+		t.Fatalf("dry run: got %+v, want only %s", res, dead)
+	}
+	if blob, _ := c.BlobPath(dead); !exists(f, blob) {
+		t.Fatal("dry run deleted a blob")
+	}
+
+	res, err = c.GC(ctx, time.Minute, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Deleted) != 1 || res.Referenced != 1 || len(res.Failed) != 0 {
+		t.Fatalf("gc: got %+v", res)
+	}
+	for h, want := range map[string]bool{live: true, dead: false, young: true} {
+		if blob, _ := c.BlobPath(h); exists(f, blob) != want {
+			t.Fatalf("blob %s: exists %v, want %v", h, !want, want)
+		}
+	}
+}
+
+func TestCASGCKeepsBlobsLinkedDuringSweep(t *testing.T) {
+	f, srv := newFakeFiler(t)
+	c := NewCASStore(NewSeaweedFSService(srv.URL), "/cas")
+	ctx := context.Background()
+
+	h, _ := c.Put(ctx, "a", strings.NewReader("hello"), 5, nil)
+	if err := c.Delete(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+	blob, _ := c.BlobPath(h)
+	f.backdate(blob, time.Hour)
+
+	// A dedup hit touches the blob. Hide its pointer from the mark phase, as if the Put had
+	// linked it after the mark phase ran.
+	if _, err := c.Put(ctx, "b", strings.NewReader("hello"), 5, nil); err != nil {
+		t.Fatal(err)
+	}
+	f.hook = func(w http.ResponseWriter, r *http.Request) bool {
+		if r.URL.Path == "/cas/names" && r.Method == http.MethodGet {
+			w.Write([]byte(`{"Path":"/cas/names","Entries":[]}`))
+			return true
+		}
+		return false
+	}
+	res, err := c.GC(ctx, time.Minute, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Deleted) != 0 || !exists(f, blob) {
+		t.Fatalf("touched blob swept: %+v", res)
+	}
+}
+
+func TestCASGCReportsFailedDeletes(t *testing.T) {
+	f, srv := newFakeFiler(t)
+	c := NewCASStore(NewSeaweedFSService(srv.URL), "/cas")
+	ctx := context.Background()
+
+	h, _ := c.Put(ctx, "a", strings.NewReader("hello"), 5, nil)
+	if err := c.Delete(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+	blob, _ := c.BlobPath(h)
+	f.backdate(blob, time.Hour)
+
+	f.hook = func(w http.ResponseWriter, r *http.Request) bool {
+		if r.Method == http.MethodDelete && r.URL.Path == blob {
+			w.WriteHeader(http.StatusInternalServerError)
+			return true
+		}
+		return false
+	}
+	res, err := c.GC(ctx, time.Minute, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Failed[h] == nil || len(res.Deleted) != 0 {
+		t.Fatalf("failed delete reported as deleted: %+v", res)
+	}
+}
+
+func TestCASPutReportsTouchFailure(t *testing.T) {
+	f, srv := newFakeFiler(t)
+	c := NewCASStore(NewSeaweedFSService(srv.URL), "/cas")
+	ctx := context.Background()
+
+	h, _ := c.Put(ctx, "a", strings.NewReader("hello"), 5, nil)
+	blob, _ := c.BlobPath(h)
+
+	uploads := 0
+	f.hook = func(w http.ResponseWriter, r *http.Request) bool {
+		if r.URL.Path != blob || r.Method != http.MethodPut && r.Method != http.MethodPost {
+			return false
+		}
+		if _, ok := r.URL.Query()["tagging"]; ok {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return true
+		}
+		uploads++
+		return false
+	}
+	if _, err := c.Put(ctx, "b", strings.NewReader("hello"), 5, nil); err == nil {
+		t.Fatal("touch failure ignored")
+	}
+	if uploads != 0 {
+		t.Fatalf("blob re-uploaded %d times after a touch failure", uploads)
+	}
+}
+
+func exists(f *fakeFiler, p string) bool {
+	_, ok := f.file(p)
+	return ok
+}
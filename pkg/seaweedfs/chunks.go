@@ -0,0 +1,472 @@
+// Package seaweedfs provides a Go client for interacting with SeaweedFS.
+// It includes chunk-level introspection and replica verification of stored files.
+// 提供 SeaweedFS 的 Go 客户端, 包括已存储文件的分片级检查与副本校验.
+package seaweedfs
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/md5"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// filerChunk mirrors a chunk in the filer's metadata JSON. Newer filers only fill Fid.
+// 对应 filer 元数据 JSON 中的分片, 较新的 filer 只填写 Fid.
+type filerChunk struct {
+	FileID          string       `json:"file_id"`
+	Fid             *filerFileID `json:"fid"`
+	Offset          int64        `json:"offset"`
+	Size            uint64       `json:"size"`
+	ModifiedTsNs    int64        `json:"modified_ts_ns"`
+	ETag            string       `json:"e_tag"`
+	IsChunkManifest bool         `json:"is_chunk_manifest"`
+	IsCompressed    bool         `json:"is_compressed"`
+	CipherKey       []byte       `json:"cipher_key"`
+}
+
+// filerFileID is the structured file id of a chunk. 分片的结构化文件 ID.
+type filerFileID struct {
+	VolumeID uint32 `json:"volume_id"`
+	FileKey  uint64 `json:"file_key"`
+	Cookie   uint32 `json:"cookie"`
+}
+
+// fileID returns the "volume,keycookie" file id of the chunk. Like SeaweedFS, leading zero bytes of
+// the key are dropped. 返回分片的 "volume,keycookie" 文件 ID, 与 SeaweedFS 一致, 会去掉键的前导零字节.
+func (c *filerChunk) fileID() string {
+	if c.FileID != "" || c.Fid == nil {
+		return c.FileID
+	}
+	b := binary.BigEndian.AppendUint64(nil, c.Fid.FileKey)
+	b = binary.BigEndian.AppendUint32(b, c.Fid.Cookie)
+	i := 0
+	for i < 8 && b[i] == 0 {
+		i++
+	}
+	return strconv.FormatUint(uint64(c.Fid.VolumeID), 10) + "," + hex.EncodeToString(b[i:])
+}
+
+// FileChunk describes one stored chunk of a file. 描述文件的一个存储分片.
+type FileChunk struct {
+	FileID     string           `json:"fileId"`              // Volume file id, e.g. "3,01637037d6" / 卷文件 ID, 如 "3,01637037d6"
+	Offset     int64            `json:"offset"`              // Offset in the file / 在文件中的偏移
+	Size       int64            `json:"size"`                // Logical size / 逻辑大小
+	Mtime      time.Time        `json:"mtime"`               // Upload time of the chunk / 分片上传时间
+	ETag       string           `json:"etag"`                // ETag returned by the volume server / 卷服务器返回的 ETag
+	Manifest   string           `json:"manifest,omitempty"`  // File id of the manifest chunk that listed it, if any / 列出该分片的清单分片文件 ID (如有)
+	Compressed bool             `json:"compressed"`          // Stored compressed by the filer / 由 filer 压缩存储
+	Encrypted  bool             `json:"encrypted"`           // Stored encrypted by the filer / 由 filer 加密存储
+	Locations  []VolumeLocation `json:"locations,omitempty"` // Volume servers holding the chunk / 持有分片的卷服务器
+}
+
+// VolumeID returns the volume the chunk is stored in. 返回分片所在的卷 ID.
+func (c FileChunk) VolumeID() string {
+	return volumeID(c.FileID)
+}
+
+// Chunks returns the data chunks of file p ordered by offset. Small files stored inline in the filer
+// have no chunks. Large files list their chunks in manifest chunks, which are read from a volume
+// server and resolved, so they need the master (see WithMasterEndpoint). If locate is true, the
+// volume servers of every chunk are looked up on the master as well.
+// 返回文件 p 按偏移排序的数据分片, 内联存储在 filer 中的小文件没有分片. 大文件的分片列在清单分片中,
+// 清单会从卷服务器读取并展开, 因此需要 master (见 WithMasterEndpoint). locate 为 true 时还会向 master
+// 查询每个分片所在的卷服务器.
+func (s *SeaweedFSService) Chunks(ctx context.Context, p string, locate bool) ([]FileChunk, error) {
+	lookup := &volumeLookup{s: s, cache: make(map[string][]VolumeLocation)}
+	chunks, err := s.chunks(ctx, p, lookup)
+	if err != nil || !locate {
+		return chunks, err
+	}
+	for i := range chunks {
+		if chunks[i].Locations, err = lookup.locations(ctx, chunks[i].VolumeID()); err != nil {
+			return chunks, fmt.Errorf("chunks %s: volume %s: %w", p, chunks[i].VolumeID(), err)
+		}
+	}
+	return chunks, nil
+}
+
+// chunks returns the data chunks of p, resolving manifest chunks. 返回 p 的数据分片, 并展开清单分片.
+func (s *SeaweedFSService) chunks(ctx context.Context, p string, lookup *volumeLookup) ([]FileChunk, error) {
+	raw, err := s.entry(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+	if raw.Mode&uint32(os.ModeDir) != 0 {
+		return nil, fmt.Errorf("chunks %s: is a directory", p)
+	}
+	chunks, err := s.expandChunks(ctx, lookup, raw.Chunks, "", 0)
+	if err != nil {
+		return nil, fmt.Errorf("chunks %s: %w", p, err)
+	}
+	sort.SliceStable(chunks, func(i, j int) bool { return chunks[i].Offset < chunks[j].Offset })
+	return chunks, nil
+}
+
+// maxManifestDepth bounds the nesting of manifest chunks. 清单分片的最大嵌套层数.
+const maxManifestDepth = 8
+
+// expandChunks converts raw chunks, replacing every manifest chunk with the chunks it lists.
+// 转换原始分片, 并将每个清单分片替换为其列出的分片.
+func (s *SeaweedFSService) expandChunks(ctx context.Context, lookup *volumeLookup, raw []filerChunk, manifest string, depth int) ([]FileChunk, error) {
+	chunks := make([]FileChunk, 0, len(raw))
+	for _, c := range raw {
+		if !c.IsChunkManifest {
+			chunks = append(chunks, FileChunk{
+				FileID:     c.fileID(),
+				Offset:     c.Offset,
+				Size:       int64(c.Size),
+				Mtime:      time.Unix(0, c.ModifiedTsNs),
+				ETag:       c.ETag,
+				Manifest:   manifest,
+				Compressed: c.IsCompressed,
+				Encrypted:  len(c.CipherKey) > 0,
+			})
+			continue
+		}
+		if depth >= maxManifestDepth {
+			return nil, fmt.Errorf("manifest %s: nested more than %d levels", c.fileID(), maxManifestDepth)
+		}
+		b, err := s.readManifest(ctx, lookup, c)
+		if err != nil {
+			return nil, fmt.Errorf("manifest %s: %w", c.fileID(), err)
+		}
+		listed, err := parseChunkManifest(b)
+		if err != nil {
+			return nil, fmt.Errorf("manifest %s: %w", c.fileID(), err)
+		}
+		sub, err := s.expandChunks(ctx, lookup, listed, c.fileID(), depth+1)
+		if err != nil {
+			return nil, err
+		}
+		chunks = append(chunks, sub...)
+	}
+	return chunks, nil
+}
+
+// readManifest reads manifest chunk c from the first volume server that serves it and undoes the
+// filer's encryption and compression.
+// 从第一个可用的卷服务器读取清单分片 c, 并还原 filer 的加密与压缩.
+func (s *SeaweedFSService) readManifest(ctx context.Context, lookup *volumeLookup, c filerChunk) ([]byte, error) {
+	locs, err := lookup.locations(ctx, volumeID(c.fileID()))
+	if err != nil {
+		return nil, err
+	}
+	if len(locs) == 0 {
+		return nil, fmt.Errorf("volume %s has no locations", volumeID(c.fileID()))
+	}
+
+	var b []byte
+	for _, loc := range locs {
+		if b, err = s.readVolumeFile(ctx, loc.URL, c.fileID()); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(c.CipherKey) > 0 {
+		if b, err = decryptChunk(b, c.CipherKey); err != nil {
+			return nil, err
+		}
+	}
+	// The volume server decompresses unless the client accepted gzip, so check the magic bytes.
+	if c.IsCompressed && bytes.HasPrefix(b, []byte{0x1f, 0x8b}) {
+		zr, err := gzip.NewReader(bytes.NewReader(b))
+		if err != nil {
+			return nil, err
+		}
+		if b, err = io.ReadAll(zr); err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+// readVolumeFile reads the file id fid from the volume server at addr. 从 addr 上的卷服务器读取文件 fid.
+func (s *SeaweedFSService) readVolumeFile(ctx context.Context, addr, fid string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.volumeURL(addr)+"/"+fid, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return nil, &StatusError{Op: "read chunk", Code: resp.StatusCode, Status: resp.Status, Body: string(b)}
+	}
+	return b, err
+}
+
+// decryptChunk reverses the filer's chunk encryption: AES-256-GCM with the nonce prepended.
+// 还原 filer 的分片加密: AES-256-GCM, nonce 位于密文之前.
+func decryptChunk(b, key []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) < gcm.NonceSize() {
+		return nil, errors.New("encrypted chunk too short")
+	}
+	return gcm.Open(nil, b[:gcm.NonceSize()], b[gcm.NonceSize():], nil)
+}
+
+// parseChunkManifest decodes a protobuf FileChunkManifest message, which is how the filer stores
+// manifests. Only the fields of filer.proto that the SDK reports are decoded:
+//
+//	message FileChunkManifest { repeated FileChunk chunks = 1; }
+//	message FileChunk { string file_id = 1; int64 offset = 2; uint64 size = 3; int64 modified_ts_ns = 4;
+//	    string e_tag = 5; FileId fid = 7; bytes cipher_key = 9; bool is_compressed = 10; bool is_chunk_manifest = 11; }
+//	message FileId { uint32 volume_id = 1; uint64 file_key = 2; fixed32 cookie = 3; }
+//
+// 解码 filer 存储清单所用的 protobuf FileChunkManifest 消息, 只解码 SDK 报告的 filer.proto 字段.
+func parseChunkManifest(b []byte) ([]filerChunk, error) {
+	var chunks []filerChunk
+	err := protoFields(b, func(num int, v uint64, data []byte) error {
+		if num != 1 {
+			return nil
+		}
+		var c filerChunk
+		err := protoFields(data, func(num int, v uint64, data []byte) error {
+			switch num {
+			case 1:
+				c.FileID = string(data)
+			case 2:
+				c.Offset = int64(v)
+			case 3:
+				c.Size = v
+			case 4:
+				c.ModifiedTsNs = int64(v)
+			case 5:
+				c.ETag = string(data)
+			case 7:
+				c.Fid = &filerFileID{}
+				return protoFields(data, func(num int, v uint64, _ []byte) error {
+					switch num {
+					case 1:
+						c.Fid.VolumeID = uint32(v)
+					case 2:
+						c.Fid.FileKey = v
+					case 3:
+						c.Fid.Cookie = uint32(v)
+					}
+					return nil
+				})
+			case 9:
+				c.CipherKey = append([]byte(nil), data...)
+			case 10:
+				c.IsCompressed = v != 0
+			case 11:
+				c.IsChunkManifest = v != 0
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		chunks = append(chunks, c)
+		return nil
+	})
+	return chunks, err
+}
+
+// protoFields calls fn for every field of the protobuf message b with its number and either its
+// numeric value or, for length-delimited fields, its bytes.
+// 对 protobuf 消息 b 的每个字段调用 fn, 传入字段编号以及数值或 (长度前缀字段的) 字节.
+func protoFields(b []byte, fn func(num int, v uint64, data []byte) error) error {
+	for len(b) > 0 {
+		key, n := binary.Uvarint(b)
+		if n <= 0 {
+			return errors.New("invalid protobuf field key")
+		}
+		b = b[n:]
+		var v uint64
+		var data []byte
+		switch key & 7 {
+		case 0: // varint
+			if v, n = binary.Uvarint(b); n <= 0 {
+				return errors.New("invalid protobuf varint")
+			}
+			b = b[n:]
+		case 1: // fixed64
+			if len(b) < 8 {
+				return io.ErrUnexpectedEOF
+			}
+			v, b = binary.LittleEndian.Uint64(b), b[8:]
+		case 2: // length-delimited
+			l, n := binary.Uvarint(b)
+			if n <= 0 || l > uint64(len(b)-n) {
+				return io.ErrUnexpectedEOF
+			}
+			data, b = b[n:n+int(l)], b[n+int(l):]
+		case 5: // fixed32
+			if len(b) < 4 {
+				return io.ErrUnexpectedEOF
+			}
+			v, b = uint64(binary.LittleEndian.Uint32(b)), b[4:]
+		default:
+			return fmt.Errorf("unsupported protobuf wire type %d", key&7)
+		}
+		if err := fn(int(key>>3), v, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ChunkCheck is the verification result of one chunk. 单个分片的校验结果.
+type ChunkCheck struct {
+	Chunk    FileChunk      // Chunk, with its locations / 分片及其位置
+	Expected int            // Replicas expected from the entry's replication / 根据条目副本策略期望的副本数
+	Replicas []ReplicaCheck // One result per location / 每个位置的校验结果
+	Err      error          // Lookup error, if the chunk could not be located / 无法定位分片时的查询错误
+}
+
+// ReplicaCheck is the verification result of one replica of a chunk. 分片单个副本的校验结果.
+type ReplicaCheck struct {
+	URL string // Volume server address / 卷服务器地址
+	Err error  // Nil if the replica was read and matched / 副本可读且匹配时为 nil
+}
+
+// Healthy returns the number of replicas that verified. 返回校验通过的副本数.
+func (c ChunkCheck) Healthy() int {
+	n := 0
+	for _, r := range c.Replicas {
+		if r.Err == nil {
+			n++
+		}
+	}
+	return n
+}
+
+// OK reports whether at least the expected number of replicas verified. 判断校验通过的副本数是否不少于期望值.
+func (c ChunkCheck) OK() bool {
+	return c.Err == nil && c.Healthy() >= c.Expected
+}
+
+// VerifyChunks range-reads every replica of every data chunk of p from its volume servers and checks
+// the size and ETag (or MD5) against the filer metadata; manifest chunks are resolved first.
+// Missing and corrupt replicas are reported per chunk; the error is only set when the entry, a
+// manifest or the master cannot be read. Filer-encrypted chunks are only checked for readability
+// and ETag, as their stored bytes differ from the logical size.
+// 按范围读取 p 每个数据分片在各卷服务器上的每个副本, 并根据 filer 元数据校验大小与 ETag (或 MD5), 清单分片会先被展开.
+// 缺失与损坏的副本按分片报告, 仅在无法读取条目、清单或查询 master 时返回错误. filer 加密的分片只校验可读性与 ETag,
+// 因为其存储字节与逻辑大小不同.
+func (s *SeaweedFSService) VerifyChunks(ctx context.Context, p string) ([]ChunkCheck, error) {
+	if s.masterEndpoint == "" {
+		return nil, ErrNoMaster
+	}
+	stat, err := s.Stat(ctx, p, false)
+	if err != nil {
+		return nil, err
+	}
+	lookup := &volumeLookup{s: s, cache: make(map[string][]VolumeLocation)}
+	chunks, err := s.chunks(ctx, p, lookup)
+	if err != nil {
+		return nil, err
+	}
+	expected := replicaCount(stat.Replication)
+
+	checks := make([]ChunkCheck, len(chunks))
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(8)
+	for i, c := range chunks {
+		checks[i] = ChunkCheck{Chunk: c, Expected: expected}
+		g.Go(func() error {
+			check := &checks[i]
+			check.Chunk.Locations, check.Err = lookup.locations(gctx, c.VolumeID())
+			for _, loc := range check.Chunk.Locations {
+				check.Replicas = append(check.Replicas, ReplicaCheck{URL: loc.URL, Err: s.verifyReplica(gctx, loc.URL, c)})
+			}
+			return gctx.Err()
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return checks, err
+	}
+	return checks, nil
+}
+
+// verifyReplica range-reads chunk c from the volume server at addr and compares it with the metadata.
+// The range covers the logical size, so a replica holding extra bytes is caught by the ETag check;
+// encrypted chunks are read to the end.
+// 从 addr 上的卷服务器按范围读取分片 c 并与元数据比较. 范围覆盖逻辑大小, 多出字节的副本由 ETag 校验发现;
+// 加密分片会读取到末尾.
+func (s *SeaweedFSService) verifyReplica(ctx context.Context, addr string, c FileChunk) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.volumeURL(addr)+"/"+c.FileID, nil)
+	if err != nil {
+		return err
+	}
+	switch {
+	case c.Encrypted:
+		req.Header.Set("Range", "bytes=0-")
+	case c.Size > 0:
+		req.Header.Set("Range", fmt.Sprintf("bytes=0-%d", c.Size-1))
+	}
+	resp, err := s.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent {
+		b, _ := io.ReadAll(resp.Body)
+		return &StatusError{Op: "read chunk", Code: resp.StatusCode, Status: resp.Status, Body: string(b)}
+	}
+
+	h := md5.New()
+	n, err := io.Copy(h, resp.Body)
+	if err != nil {
+		return err
+	}
+	if !c.Encrypted && n != c.Size {
+		return fmt.Errorf("chunk %s: size mismatch: got %d, want %d", c.FileID, n, c.Size)
+	}
+
+	want := strings.Trim(c.ETag, `"`)
+	if want == "" {
+		return nil
+	}
+	// Volume servers answer with the needle ETag; chunks uploaded with a content MD5 record that instead.
+	if got := strings.Trim(resp.Header.Get("ETag"), `"`); got == want {
+		return nil
+	}
+	if md5Hex(want) == hex.EncodeToString(h.Sum(nil)) {
+		return nil
+	}
+	return fmt.Errorf("chunk %s: etag mismatch: got %s, want %s", c.FileID, resp.Header.Get("ETag"), c.ETag)
+}
+
+// replicaCount returns the number of copies a replication setting such as "001" asks for.
+// 返回副本策略 (如 "001") 要求的副本总数.
+func replicaCount(replication string) int {
+	n := 1
+	if Replication(replication).Validate() != nil {
+		return n
+	}
+	for _, d := range replication {
+		v, _ := strconv.Atoi(string(d))
+		n += v
+	}
+	return n
+}
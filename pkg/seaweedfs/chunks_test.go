@@ -0,0 +1,143 @@
+package seaweedfs
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/md5"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeVolume serves file ids with Range support and records the Range headers it saw.
+type fakeVolume struct {
+	mu     sync.Mutex
+	files  map[string][]byte
+	ranges []string
+}
+
+func (v *fakeVolume) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.ranges = append(v.ranges, r.Header.Get("Range"))
+	b, ok := v.files[strings.TrimPrefix(r.URL.Path, "/")]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	var start, end int
+	if _, err := fmt.Sscanf(r.Header.Get("Range"), "bytes=%d-%d", &start, &end); err == nil {
+		w.WriteHeader(http.StatusPartialContent)
+		w.Write(b[start:min(end+1, len(b))])
+		return
+	}
+	w.Write(b)
+}
+
+// protoField appends a protobuf field: a varint for uint64 values, length-delimited for []byte.
+func protoField(b []byte, num int, v any) []byte {
+	switch v := v.(type) {
+	case uint64:
+		b = binary.AppendUvarint(b, uint64(num)<<3)
+		return binary.AppendUvarint(b, v)
+	case uint32: // fixed32
+		b = binary.AppendUvarint(b, uint64(num)<<3|5)
+		return binary.LittleEndian.AppendUint32(b, v)
+	case []byte:
+		b = binary.AppendUvarint(b, uint64(num)<<3|2)
+		b = binary.AppendUvarint(b, uint64(len(v)))
+		return append(b, v...)
+	}
+	panic("unsupported field type")
+}
+
+func md5String(b []byte) string {
+	sum := md5.Sum(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func TestChunksResolvesManifests(t *testing.T) {
+	a, b := []byte("hello"), []byte("world")
+
+	// The second chunk only carries the structured file id 2,03 with cookie 0x0c.
+	var second []byte
+	second = protoField(second, 2, uint64(5))
+	second = protoField(second, 3, uint64(5))
+	second = protoField(second, 5, []byte(md5String(b)))
+	fid := protoField(protoField(protoField(nil, 1, uint64(2)), 2, uint64(3)), 3, uint32(0x0c))
+	second = protoField(second, 7, fid)
+	var first []byte
+	first = protoField(first, 1, []byte("2,010000000b"))
+	first = protoField(first, 3, uint64(5))
+	first = protoField(first, 5, []byte(md5String(a)))
+	manifest := protoField(protoField(nil, 1, second), 1, first)
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	zw.Write(manifest)
+	zw.Close()
+
+	vol1 := &fakeVolume{files: map[string][]byte{"1,0a0000000d": gz.Bytes(), "2,010000000b": a, "2,030000000c": b}}
+	vol2 := &fakeVolume{files: map[string][]byte{"1,0a0000000d": gz.Bytes(), "2,010000000b": []byte("hellx"), "2,030000000c": b[:3]}}
+	v1, v2 := httptest.NewServer(vol1), httptest.NewServer(vol2)
+	t.Cleanup(v1.Close)
+	t.Cleanup(v2.Close)
+	master := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{"locations": []VolumeLocation{{URL: v1.URL}, {URL: v2.URL}}})
+	}))
+	t.Cleanup(master.Close)
+
+	f, srv := newFakeFiler(t)
+	f.hook = func(w http.ResponseWriter, r *http.Request) bool {
+		if r.URL.Path != "/big" || r.URL.Query().Get("metadata") != "true" {
+			return false
+		}
+		json.NewEncoder(w).Encode(map[string]any{
+			"FullPath": "/big", "FileSize": 10, "Replication": "001",
+			"chunks": []map[string]any{{"file_id": "1,0a0000000d", "size": 40, "is_chunk_manifest": true, "is_compressed": true}},
+		})
+		return true
+	}
+	ctx := context.Background()
+
+	if _, err := NewSeaweedFSService(srv.URL).Chunks(ctx, "/big", false); err == nil {
+		t.Fatal("manifest resolved without a master")
+	}
+
+	s := NewSeaweedFSService(srv.URL, WithMasterEndpoint(master.URL))
+	chunks, err := s.Chunks(ctx, "/big", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chunks) != 2 || chunks[0].FileID != "2,010000000b" || chunks[1].FileID != "2,030000000c" ||
+		chunks[1].Offset != 5 || chunks[0].Manifest != "1,0a0000000d" || len(chunks[1].Locations) != 2 {
+		t.Fatalf("chunks: %+v", chunks)
+	}
+
+	checks, err := s.VerifyChunks(ctx, "/big")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, c := range checks {
+		if c.Expected != 2 || c.Healthy() != 1 || c.Replicas[0].Err != nil || c.Replicas[1].Err == nil || c.OK() {
+			t.Fatalf("check %d: %+v", i, c)
+		}
+	}
+	vol1.mu.Lock()
+	defer vol1.mu.Unlock()
+	for _, want := range []string{"bytes=0-4"} {
+		found := false
+		for _, r := range vol1.ranges {
+			found = found || r == want
+		}
+		if !found {
+			t.Fatalf("replicas not range-read: %q", vol1.ranges)
+		}
+	}
+}
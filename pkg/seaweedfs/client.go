@@ -4,11 +4,16 @@
 package seaweedfs
 
 import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"math/rand/v2"
 	"net/http"
 	"strings"
 	"time"
 
 	"github.com/GoFurry/seaweedfs-sdk-go/internal/policy"
+	"github.com/GoFurry/seaweedfs-sdk-go/internal/ratelimit"
 )
 
 // UploadMethod represents the HTTP method used for uploading. 表示上传使用的 HTTP 方法.
@@ -32,9 +37,26 @@ type UploadLargeOptions struct {
 // SeaweedFSService represents a SeaweedFS client service.
 // SeaweedFS 客户端服务.
 type SeaweedFSService struct {
-	FilerEndpoint string
-	client        *http.Client
-	policy        policy.SafetyPolicy
+	FilerEndpoint   string
+	masterEndpoint  string
+	client          *http.Client
+	policy          policy.SafetyPolicy
+	tokens          TokenSource
+	volumeTokens    TokenSource // Nil to sign volume requests with tokens / 为 nil 时 volume 请求使用 tokens 签名
+	tlsConfig       *tls.Config
+	compression     Codec
+	compressMinSize int64
+	lockDir         string
+	lockHolder      string
+	trashDir        string
+	collection      string        // Default upload collection / 默认上传集合
+	replication     Replication   // Default upload replication / 默认上传副本策略
+	quotas          *quotaManager // Nil unless WithQuotas is used / 未使用 WithQuotas 时为 nil
+	uploadLimit     *ratelimit.Limiter
+	downloadLimit   *ratelimit.Limiter
+	requestLimit    *ratelimit.Limiter
+	breaker         *policy.Breaker // Nil when the policy disables it / 策略未启用时为 nil
+	configErr       error           // Deferred option error reported on the first request / 延迟到首次请求时返回的配置错误
 }
 
 // DefaultSeaweedFSClient creates a default HTTP client for SeaweedFS with reasonable timeouts and connection limits.
@@ -52,14 +74,11 @@ func DefaultSeaweedFSClient() *http.Client {
 	}
 }
 
-// NewSeaweedFSService creates a new SeaweedFSService instance with default HTTP client and safety policy.
-// 创建 SeaweedFSService 实例, 使用默认 HTTP 客户端和安全策略.
-func NewSeaweedFSService(endpoint string) *SeaweedFSService {
-	return &SeaweedFSService{
-		FilerEndpoint: strings.TrimRight(endpoint, "/"),
-		client:        DefaultSeaweedFSClient(),
-		policy:        policy.DefaultSafetyPolicy(),
-	}
+// NewSeaweedFSService creates a new SeaweedFSService instance with default HTTP client and safety policy,
+// and optional configuration options.
+// 创建 SeaweedFSService 实例, 使用默认 HTTP 客户端和安全策略, 支持可选配置.
+func NewSeaweedFSService(endpoint string, opts ...Option) *SeaweedFSService {
+	return NewSeaweedFSServiceWithClient(endpoint, nil, opts...)
 }
 
 // NewSeaweedFSServiceWithClient creates a new SeaweedFSService instance with a custom HTTP client
@@ -73,10 +92,17 @@ func NewSeaweedFSServiceWithClient(endpoint string, client *http.Client, opts ..
 		FilerEndpoint: strings.TrimRight(endpoint, "/"),
 		client:        client,
 		policy:        policy.DefaultSafetyPolicy(),
+		lockDir:       defaultLockDir,
+		lockHolder:    defaultLockHolder(),
+		uploadLimit:   ratelimit.New(0, 0),
+		downloadLimit: ratelimit.New(0, 0),
+		requestLimit:  ratelimit.New(0, 0),
 	}
 	for _, opt := range opts {
 		opt(s)
 	}
+	s.applyTLS()
+	s.breaker = policy.NewBreaker(s.policy)
 	return s
 }
 
@@ -129,3 +155,85 @@ func WithBackoff(base, max time.Duration) Option {
 		}
 	}
 }
+
+// do sends an HTTP request through the service client, applying authentication first.
+// Request bodies and response bodies are throttled by the bandwidth limits in effect for the request context,
+// and filer requests fail fast with ErrCircuitOpen while the circuit breaker is open; master and volume
+// requests bypass the breaker so that one unhealthy server cannot trip it for the filer.
+// Option errors (e.g. an unreadable CA file) are reported here.
+// 通过服务客户端发送 HTTP 请求, 发送前附加鉴权信息, 请求体与响应体受该请求 context 生效的带宽限制约束,
+// 熔断器打开时 filer 请求以 ErrCircuitOpen 快速失败; master 与 volume 请求不经过熔断器, 以免单个异常服务器
+// 导致 filer 熔断. 配置错误 (如 CA 文件不可读) 在此返回.
+func (s *SeaweedFSService) do(req *http.Request) (*http.Response, error) {
+	if s.configErr != nil {
+		return nil, s.configErr
+	}
+	if err := s.authorize(req); err != nil {
+		return nil, err
+	}
+
+	var breaker *policy.Breaker
+	if s.target(req.URL) == targetFiler {
+		breaker = s.breaker
+	}
+	if err := breaker.Allow(); err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, fmt.Errorf("%s %s: %w", req.Method, req.URL.Path, err)
+	}
+
+	ctx := req.Context()
+	upload, download, request := s.limiters(ctx)
+	if err := request.Wait(ctx); err != nil {
+		breaker.Record(policy.OutcomeIgnored)
+		return nil, err
+	}
+	if req.Body != nil && req.Body != http.NoBody {
+		req.Body = ratelimit.ReadCloser(ctx, req.Body, upload)
+	}
+
+	resp, err := s.client.Do(req)
+	breaker.Record(outcome(req, resp, err))
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ratelimit.ReadCloser(ctx, resp.Body, download)
+	return resp, nil
+}
+
+// backoff sleeps for the policy's exponential backoff with jitter for the given attempt,
+// returning early with the context error if ctx is done.
+// 按安全策略的指数回退 (带抖动) 等待, ctx 结束时提前返回其错误.
+func (s *SeaweedFSService) backoff(ctx context.Context, attempt int) error {
+	sleep := s.policy.BackoffBase * (1 << attempt)
+	if sleep > s.policy.BackoffMax || sleep <= 0 {
+		sleep = s.policy.BackoffMax
+	}
+	sleep = time.Duration(float64(sleep) * (0.5 + rand.Float64()/2))
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(sleep):
+		return nil
+	}
+}
+
+// retry runs fn until it succeeds, returns a non-retryable error, or the policy's
+// UploadMaxRetry attempts are exhausted.
+// 重复执行 fn, 直到成功、返回不可重试错误或用尽安全策略的 UploadMaxRetry 次数.
+func (s *SeaweedFSService) retry(ctx context.Context, fn func() error) error {
+	var err error
+	for attempt := 0; attempt <= s.policy.UploadMaxRetry; attempt++ {
+		if err = fn(); err == nil || !policy.ShouldRetryUpload(err) {
+			return err
+		}
+		if attempt < s.policy.UploadMaxRetry {
+			if berr := s.backoff(ctx, attempt); berr != nil {
+				return berr
+			}
+		}
+	}
+	return err
+}
@@ -0,0 +1,331 @@
+// Package seaweedfs provides a Go client for interacting with SeaweedFS.
+// It includes typed clients for the cluster status and topology endpoints of masters and volume servers.
+// 提供 SeaweedFS 的 Go 客户端, 包括 master 与卷服务器集群状态、拓扑接口的类型化客户端.
+package seaweedfs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DefaultVolumeSizeLimit is the master's default volume size limit (-volumeSizeLimitMB=30000).
+// master 默认的卷大小上限 (-volumeSizeLimitMB=30000).
+const DefaultVolumeSizeLimit int64 = 30000 << 20
+
+// ClusterStatus is the result of the master's /cluster/status endpoint. master /cluster/status 接口的结果.
+type ClusterStatus struct {
+	IsLeader    bool     `json:"IsLeader"`    // Whether the queried master is the leader / 被查询的 master 是否为 leader
+	Leader      string   `json:"Leader"`      // Address of the current leader / 当前 leader 地址
+	Peers       []string `json:"Peers"`       // Other masters of the raft group / raft 组中的其他 master
+	MaxVolumeID uint32   `json:"MaxVolumeId"` // Largest volume id assigned so far / 目前分配的最大卷 ID
+}
+
+// Topology is the cluster layout reported by the master. master 报告的集群布局.
+type Topology struct {
+	Max         int64            `json:"Max"`         // Total volume slots / 卷槽位总数
+	Free        int64            `json:"Free"`        // Free volume slots / 空闲卷槽位数
+	DataCenters []DataCenterInfo `json:"DataCenters"` // Data centers, sorted by id / 数据中心, 按 ID 排序
+	Layouts     []VolumeLayout   `json:"Layouts"`     // Volume layouts, only from /dir/status / 卷布局, 仅 /dir/status 提供
+}
+
+// DataCenterInfo is a data center of the topology. 拓扑中的数据中心.
+type DataCenterInfo struct {
+	ID    string     `json:"Id"`    // Data center id / 数据中心 ID
+	Racks []RackInfo `json:"Racks"` // Racks, sorted by id / 机架, 按 ID 排序
+}
+
+// RackInfo is a rack of a data center. 数据中心中的机架.
+type RackInfo struct {
+	ID    string     `json:"Id"`        // Rack id / 机架 ID
+	Nodes []NodeInfo `json:"DataNodes"` // Volume servers, sorted by URL / 卷服务器, 按 URL 排序
+}
+
+// NodeInfo is a volume server of a rack. /dir/status fills the counters, /vol/status fills VolumeList.
+// 机架中的卷服务器, /dir/status 填充计数字段, /vol/status 填充 VolumeList.
+type NodeInfo struct {
+	URL        string       `json:"Url"`                  // Internal address, host:port / 内部地址 host:port
+	PublicURL  string       `json:"PublicUrl"`            // Public address, host:port / 公开地址 host:port
+	Volumes    int64        `json:"Volumes"`              // Number of volumes / 卷数量
+	EcShards   int64        `json:"EcShards"`             // Number of erasure-coded shards / 纠删码分片数量
+	Max        int64        `json:"Max"`                  // Volume slots / 卷槽位数
+	VolumeIDs  string       `json:"VolumeIds"`            // Volume ids, e.g. "1-3 7" / 卷 ID, 如 "1-3 7"
+	VolumeList []VolumeInfo `json:"VolumeList,omitempty"` // Volumes hosted, only from /vol/status / 托管的卷, 仅 /vol/status 提供
+}
+
+// FreeSlots returns the number of volumes the node can still create. 返回节点还能创建的卷数量.
+func (n NodeInfo) FreeSlots() int64 {
+	if free := n.Max - n.Volumes; free > 0 {
+		return free
+	}
+	return 0
+}
+
+// VolumeLayout groups the writable volumes sharing a collection, replication and TTL.
+// 共享集合、副本策略与 TTL 的可写卷分组.
+type VolumeLayout struct {
+	Collection  string   `json:"collection"`  // Collection / 集合
+	Replication string   `json:"replication"` // Replica placement, e.g. "001" / 副本策略, 如 "001"
+	TTL         string   `json:"ttl"`         // TTL, e.g. "3d" / 生存时间, 如 "3d"
+	DiskType    string   `json:"diskType"`    // Disk type, "" for hdd / 磁盘类型, hdd 为空
+	Writables   []uint32 `json:"writables"`   // Writable volume ids / 可写卷 ID
+}
+
+// VolumeInfo describes one replica of a volume. 描述卷的一个副本.
+type VolumeInfo struct {
+	ID               uint32 `json:"Id"`               // Volume id / 卷 ID
+	Collection       string `json:"Collection"`       // Collection / 集合
+	Size             int64  `json:"Size"`             // Size on disk in bytes / 磁盘占用 (字节)
+	FileCount        int64  `json:"FileCount"`        // Files stored, including deleted / 存储的文件数 (含已删除)
+	DeleteCount      int64  `json:"DeleteCount"`      // Deleted files / 已删除文件数
+	DeletedByteCount int64  `json:"DeletedByteCount"` // Bytes held by deleted files / 已删除文件占用的字节
+	ReadOnly         bool   `json:"ReadOnly"`         // Whether the volume is read-only / 是否只读
+	Replication      string `json:"Replication"`      // Replica placement, e.g. "001" / 副本策略, 如 "001"
+	TTL              string `json:"Ttl"`              // TTL, e.g. "3d" / 生存时间, 如 "3d"
+	DiskType         string `json:"DiskType"`         // Disk type, "" for hdd / 磁盘类型, hdd 为空
+	Version          int    `json:"Version"`          // Needle format version / needle 格式版本
+	CompactRevision  uint32 `json:"CompactRevision"`  // Times the volume was vacuumed / 卷被压缩的次数
+	ModifiedAtSecond int64  `json:"ModifiedAtSecond"` // Last modification, unix seconds / 最后修改时间 (unix 秒)
+}
+
+// UnmarshalJSON decodes the volume server representation, where the replica placement and TTL are objects.
+// 解码卷服务器的表示形式, 其中副本策略与 TTL 为对象.
+func (v *VolumeInfo) UnmarshalJSON(b []byte) error {
+	type plain VolumeInfo
+	var raw struct {
+		plain
+		ReplicaPlacement *struct {
+			Node int `json:"node"`
+			Rack int `json:"rack"`
+			DC   int `json:"dc"`
+		} `json:"ReplicaPlacement"`
+		TTL json.RawMessage `json:"Ttl"`
+	}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	*v = VolumeInfo(raw.plain)
+	if rp := raw.ReplicaPlacement; rp != nil {
+		v.Replication = fmt.Sprintf("%d%d%d", rp.DC, rp.Rack, rp.Node)
+	}
+	if len(raw.TTL) == 0 || json.Unmarshal(raw.TTL, &v.TTL) == nil {
+		return nil
+	}
+	var ttl struct {
+		Count int  `json:"Count"`
+		Unit  byte `json:"Unit"`
+	}
+	if err := json.Unmarshal(raw.TTL, &ttl); err != nil {
+		return err
+	}
+	// TTL units as encoded by the volume server: 1=m 2=h 3=d 4=w 5=M 6=y.
+	if ttl.Count > 0 && ttl.Unit >= 1 && ttl.Unit <= 6 {
+		v.TTL = fmt.Sprintf("%d%c", ttl.Count, "mhdwMy"[ttl.Unit-1])
+	}
+	return nil
+}
+
+// VolumeServerStatus is the result of a volume server's /status endpoint. 卷服务器 /status 接口的结果.
+type VolumeServerStatus struct {
+	Version string       `json:"Version"`      // Server version / 服务器版本
+	Volumes []VolumeInfo `json:"Volumes"`      // Volumes hosted / 托管的卷
+	Disks   []DiskStatus `json:"DiskStatuses"` // Disk usage per data directory / 每个数据目录的磁盘用量
+}
+
+// DiskStatus is the usage of one data directory of a volume server. 卷服务器单个数据目录的用量.
+type DiskStatus struct {
+	Dir         string  `json:"dir"`          // Data directory / 数据目录
+	All         uint64  `json:"all"`          // Capacity in bytes / 容量 (字节)
+	Used        uint64  `json:"used"`         // Used bytes / 已用字节
+	Free        uint64  `json:"free"`         // Free bytes / 空闲字节
+	PercentFree float32 `json:"percent_free"` // Free percentage / 空闲百分比
+	PercentUsed float32 `json:"percent_used"` // Used percentage / 已用百分比
+	DiskType    string  `json:"disk_type"`    // Disk type / 磁盘类型
+}
+
+// CollectionUsage aggregates the volumes of one collection. Replicas of a volume are counted once,
+// using the largest replica. 汇总单个集合的卷, 同一卷的副本只计一次, 取最大的副本.
+type CollectionUsage struct {
+	Collection   string `json:"collection"`   // Collection, "" for the default one / 集合, 默认集合为空
+	Volumes      int    `json:"volumes"`      // Distinct volumes / 不同卷的数量
+	Replicas     int    `json:"replicas"`     // Volume replicas / 卷副本数量
+	ReadOnly     int    `json:"readOnly"`     // Volumes with a read-only replica / 存在只读副本的卷数量
+	Size         int64  `json:"size"`         // Bytes used / 已用字节
+	FileCount    int64  `json:"fileCount"`    // Files stored, including deleted / 存储的文件数 (含已删除)
+	DeleteCount  int64  `json:"deleteCount"`  // Deleted files / 已删除文件数
+	DeletedBytes int64  `json:"deletedBytes"` // Bytes reclaimable by vacuum / 可由压缩回收的字节
+	Free         int64  `json:"free"`         // Bytes left in writable volumes / 可写卷中的剩余字节
+}
+
+// ClusterStatus returns the raft status of the master. 返回 master 的 raft 状态.
+func (s *SeaweedFSService) ClusterStatus(ctx context.Context) (*ClusterStatus, error) {
+	var out ClusterStatus
+	if err := s.masterJSON(ctx, "cluster status", "/cluster/status", nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Topology returns the data centers, racks and volume servers with their slot counts, and the
+// writable volume layouts, from the master's /dir/status endpoint.
+// 从 master 的 /dir/status 接口返回数据中心、机架、卷服务器及其槽位数, 以及可写卷布局.
+func (s *SeaweedFSService) Topology(ctx context.Context) (*Topology, error) {
+	var out struct {
+		Topology Topology `json:"Topology"`
+	}
+	if err := s.masterJSON(ctx, "topology", "/dir/status", nil, &out); err != nil {
+		return nil, err
+	}
+	t := &out.Topology
+	t.sort()
+	return t, nil
+}
+
+// VolumeStatus returns every volume replica of the cluster grouped by data center, rack and volume
+// server, from the master's /vol/status endpoint. Only the VolumeList of the nodes is filled.
+// 从 master 的 /vol/status 接口返回集群中的所有卷副本, 按数据中心、机架与卷服务器分组, 节点仅填充 VolumeList.
+func (s *SeaweedFSService) VolumeStatus(ctx context.Context) (*Topology, error) {
+	var out struct {
+		Volumes struct {
+			Max         int64                                         `json:"Max"`
+			Free        int64                                         `json:"Free"`
+			DataCenters map[string]map[string]map[string][]VolumeInfo `json:"DataCenters"`
+		} `json:"Volumes"`
+	}
+	if err := s.masterJSON(ctx, "volume status", "/vol/status", nil, &out); err != nil {
+		return nil, err
+	}
+
+	t := &Topology{Max: out.Volumes.Max, Free: out.Volumes.Free}
+	for dcID, racks := range out.Volumes.DataCenters {
+		dc := DataCenterInfo{ID: dcID}
+		for rackID, nodes := range racks {
+			rack := RackInfo{ID: rackID}
+			for url, vols := range nodes {
+				rack.Nodes = append(rack.Nodes, NodeInfo{URL: url, Volumes: int64(len(vols)), VolumeList: vols})
+			}
+			dc.Racks = append(dc.Racks, rack)
+		}
+		t.DataCenters = append(t.DataCenters, dc)
+	}
+	t.sort()
+	return t, nil
+}
+
+// VolumeServerStatus returns the volumes and disk usage of the volume server at addr (host:port or URL).
+// 返回 addr (host:port 或 URL) 上卷服务器的卷与磁盘用量.
+func (s *SeaweedFSService) VolumeServerStatus(ctx context.Context, addr string) (*VolumeServerStatus, error) {
+	var out VolumeServerStatus
+	if err := s.getJSON(ctx, "volume server status", s.volumeURL(addr)+"/status", nil, &out); err != nil {
+		return nil, err
+	}
+	sort.Slice(out.Volumes, func(i, j int) bool { return out.Volumes[i].ID < out.Volumes[j].ID })
+	return &out, nil
+}
+
+// CollectionUsage returns the usage of every collection, keyed by name, from the master's /vol/status
+// endpoint. volumeSizeLimit is the master's -volumeSizeLimitMB in bytes; 0 means DefaultVolumeSizeLimit.
+// 从 master 的 /vol/status 接口返回各集合的用量, 以集合名为键. volumeSizeLimit 为 master 的
+// -volumeSizeLimitMB (字节), 0 表示 DefaultVolumeSizeLimit.
+func (s *SeaweedFSService) CollectionUsage(ctx context.Context, volumeSizeLimit int64) (map[string]*CollectionUsage, error) {
+	t, err := s.VolumeStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return t.CollectionUsage(volumeSizeLimit), nil
+}
+
+// Nodes returns all volume servers of the topology. 返回拓扑中的所有卷服务器.
+func (t *Topology) Nodes() []NodeInfo {
+	var nodes []NodeInfo
+	for _, dc := range t.DataCenters {
+		for _, rack := range dc.Racks {
+			nodes = append(nodes, rack.Nodes...)
+		}
+	}
+	return nodes
+}
+
+// FreeSlots returns the number of volumes the cluster can still create. 返回集群还能创建的卷数量.
+func (t *Topology) FreeSlots() int64 {
+	if t.Free > 0 {
+		return t.Free
+	}
+	var free int64
+	for _, n := range t.Nodes() {
+		free += n.FreeSlots()
+	}
+	return free
+}
+
+// CollectionUsage aggregates the VolumeList of the nodes per collection. Free counts the bytes left
+// below volumeSizeLimit in volumes without a read-only replica; free slots are shared by all
+// collections and not included. 0 means DefaultVolumeSizeLimit.
+// 按集合汇总节点的 VolumeList. Free 统计没有只读副本的卷在 volumeSizeLimit 以下的剩余字节;
+// 空闲槽位由所有集合共享, 不计入其中. 0 表示 DefaultVolumeSizeLimit.
+func (t *Topology) CollectionUsage(volumeSizeLimit int64) map[string]*CollectionUsage {
+	if volumeSizeLimit <= 0 {
+		volumeSizeLimit = DefaultVolumeSizeLimit
+	}
+
+	// Merge the replicas of each volume first.
+	volumes := make(map[uint32]*VolumeInfo)
+	replicas := make(map[uint32]int)
+	for _, n := range t.Nodes() {
+		for _, v := range n.VolumeList {
+			replicas[v.ID]++
+			m, ok := volumes[v.ID]
+			if !ok {
+				v := v
+				volumes[v.ID] = &v
+				continue
+			}
+			m.ReadOnly = m.ReadOnly || v.ReadOnly
+			if v.Size > m.Size {
+				m.Size, m.FileCount, m.DeleteCount, m.DeletedByteCount = v.Size, v.FileCount, v.DeleteCount, v.DeletedByteCount
+			}
+		}
+	}
+
+	usage := make(map[string]*CollectionUsage)
+	for id, v := range volumes {
+		u, ok := usage[v.Collection]
+		if !ok {
+			u = &CollectionUsage{Collection: v.Collection}
+			usage[v.Collection] = u
+		}
+		u.Volumes++
+		u.Replicas += replicas[id]
+		u.Size += v.Size
+		u.FileCount += v.FileCount
+		u.DeleteCount += v.DeleteCount
+		u.DeletedBytes += v.DeletedByteCount
+		if v.ReadOnly {
+			u.ReadOnly++
+		} else if v.Size < volumeSizeLimit {
+			u.Free += volumeSizeLimit - v.Size
+		}
+	}
+	return usage
+}
+
+// sort orders data centers, racks and nodes so results are stable. 对数据中心、机架与节点排序, 使结果稳定.
+func (t *Topology) sort() {
+	sort.Slice(t.DataCenters, func(i, j int) bool { return t.DataCenters[i].ID < t.DataCenters[j].ID })
+	for _, dc := range t.DataCenters {
+		sort.Slice(dc.Racks, func(i, j int) bool { return dc.Racks[i].ID < dc.Racks[j].ID })
+		for _, rack := range dc.Racks {
+			sort.Slice(rack.Nodes, func(i, j int) bool { return rack.Nodes[i].URL < rack.Nodes[j].URL })
+			for _, n := range rack.Nodes {
+				sort.Slice(n.VolumeList, func(i, j int) bool { return n.VolumeList[i].ID < n.VolumeList[j].ID })
+			}
+		}
+	}
+	sort.Slice(t.Layouts, func(i, j int) bool {
+		a, b := t.Layouts[i], t.Layouts[j]
+		return strings.Join([]string{a.Collection, a.Replication, a.TTL, a.DiskType}, "\x00") <
+			strings.Join([]string{b.Collection, b.Replication, b.TTL, b.DiskType}, "\x00")
+	})
+}
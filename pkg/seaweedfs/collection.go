@@ -0,0 +1,135 @@
+// Package seaweedfs provides a Go client for interacting with SeaweedFS.
+// It includes collection management through the master and default upload placement.
+// 提供 SeaweedFS 的 Go 客户端, 包括通过 master 管理集合以及默认上传位置.
+package seaweedfs
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+)
+
+// WithDefaultCollection sets the collection used by uploads that do not name one, so that e.g. each
+// tenant of a multi-tenant application can get its own service and collection.
+// 设置未指定集合的上传所使用的集合, 例如多租户应用可为每个租户使用独立的服务与集合.
+func WithDefaultCollection(collection string) Option {
+	return func(s *SeaweedFSService) {
+		s.collection = collection
+	}
+}
+
+// WithDefaultReplication sets the replication used by uploads that do not name one, e.g. "001".
+// An invalid value is reported by the first request.
+// 设置未指定副本策略的上传所使用的副本策略, 如 "001", 无效值会在首次请求时返回错误.
+func WithDefaultReplication(replication Replication) Option {
+	return func(s *SeaweedFSService) {
+		if err := replication.Validate(); err != nil {
+			s.configErr = err
+			return
+		}
+		s.replication = replication
+	}
+}
+
+// uploadDefaults adds the default collection and replication to the upload query parameters
+// opts unless they are already set. opts itself is not modified.
+// 在上传查询参数 opts 未设置时补充默认集合与副本策略, 不修改 opts 本身.
+func (s *SeaweedFSService) uploadDefaults(opts map[string]string) map[string]string {
+	_, hasCol := opts["collection"]
+	_, hasRep := opts["replication"]
+	if (s.collection == "" || hasCol) && (s.replication == "" || hasRep) {
+		return opts
+	}
+
+	out := make(map[string]string, len(opts)+2)
+	for k, v := range opts {
+		out[k] = v
+	}
+	if s.collection != "" && !hasCol {
+		out["collection"] = s.collection
+	}
+	if s.replication != "" && !hasRep {
+		out["replication"] = string(s.replication)
+	}
+	return out
+}
+
+// ListCollections returns the names of the collections that have volumes, sorted. The default
+// collection, whose name is empty, is not included.
+// 返回拥有卷的集合名称 (已排序), 不包含名称为空的默认集合.
+func (s *SeaweedFSService) ListCollections(ctx context.Context) ([]string, error) {
+	t, err := s.VolumeStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	names := []string{}
+	for _, n := range t.Nodes() {
+		for _, v := range n.VolumeList {
+			if v.Collection != "" && !seen[v.Collection] {
+				seen[v.Collection] = true
+				names = append(names, v.Collection)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// CollectionStats returns the usage of one collection ("" for the default one). volumeSizeLimit is
+// the master's -volumeSizeLimitMB in bytes; 0 means DefaultVolumeSizeLimit. A collection without
+// volumes is reported as os.ErrNotExist.
+// 返回单个集合 ("" 表示默认集合) 的用量. volumeSizeLimit 为 master 的 -volumeSizeLimitMB (字节),
+// 0 表示 DefaultVolumeSizeLimit. 没有卷的集合返回 os.ErrNotExist.
+func (s *SeaweedFSService) CollectionStats(ctx context.Context, collection string, volumeSizeLimit int64) (*CollectionUsage, error) {
+	usage, err := s.CollectionUsage(ctx, volumeSizeLimit)
+	if err != nil {
+		return nil, err
+	}
+	u, ok := usage[collection]
+	if !ok {
+		return nil, fmt.Errorf("collection %q: %w", collection, os.ErrNotExist)
+	}
+	return u, nil
+}
+
+// DeleteCollection drops a collection and all of its volumes on every volume server. This cannot
+// be undone, and filer entries whose chunks lived in the collection are not removed. The default
+// collection cannot be deleted; an unknown collection is reported as os.ErrNotExist.
+// 删除集合及其在所有卷服务器上的卷. 该操作不可撤销, 且分片位于该集合的 filer 条目不会被删除.
+// 默认集合不能删除, 未知集合返回 os.ErrNotExist.
+func (s *SeaweedFSService) DeleteCollection(ctx context.Context, collection string) error {
+	if collection == "" {
+		return fmt.Errorf("delete collection: the default collection cannot be deleted: %w", os.ErrInvalid)
+	}
+	if s.masterEndpoint == "" {
+		return ErrNoMaster
+	}
+
+	u := s.masterEndpoint + "/col/delete?" + url.Values{"collection": {collection}}.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := s.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		b, _ := io.ReadAll(resp.Body)
+		// The master answers 400 with "collection xxx does not exist" for unknown collections.
+		if strings.Contains(string(b), "does not exist") {
+			return fmt.Errorf("delete collection %q: %w", collection, os.ErrNotExist)
+		}
+		return &StatusError{Op: "delete collection", Code: resp.StatusCode, Status: resp.Status, Body: string(b)}
+	}
+	return nil
+}
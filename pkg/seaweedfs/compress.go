@@ -0,0 +1,221 @@
+// Package seaweedfs provides a Go client for interacting with SeaweedFS.
+// It includes transparent compression on upload and decompression on download.
+// 提供 SeaweedFS 的 Go 客户端, 包括上传时透明压缩与下载时自动解压.
+package seaweedfs
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Compression metadata is stored as file tags. 压缩元数据以文件标签形式存储.
+const (
+	compressTagCodec = "Compression"
+	compressTagSize  = "Compression-Size"
+)
+
+// Codec compresses and decompresses file contents. Implementations for zstd or snappy
+// can be plugged in with RegisterCodec and WithCompression.
+// 压缩与解压文件内容, zstd 或 snappy 等实现可通过 RegisterCodec 与 WithCompression 接入.
+type Codec interface {
+	Name() string
+	NewWriter(w io.Writer) (io.WriteCloser, error)
+	NewReader(r io.Reader) (io.ReadCloser, error)
+}
+
+// Built-in codecs. 内置编解码器.
+var (
+	GzipCodec    Codec = gzipCodec{}
+	DeflateCodec Codec = deflateCodec{}
+)
+
+type gzipCodec struct{}
+
+func (gzipCodec) Name() string                                  { return "gzip" }
+func (gzipCodec) NewWriter(w io.Writer) (io.WriteCloser, error) { return gzip.NewWriter(w), nil }
+func (gzipCodec) NewReader(r io.Reader) (io.ReadCloser, error)  { return gzip.NewReader(r) }
+
+type deflateCodec struct{}
+
+func (deflateCodec) Name() string { return "deflate" }
+func (deflateCodec) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return flate.NewWriter(w, flate.DefaultCompression)
+}
+func (deflateCodec) NewReader(r io.Reader) (io.ReadCloser, error) { return flate.NewReader(r), nil }
+
+var (
+	codecsMu sync.RWMutex
+	codecs   = map[string]Codec{
+		GzipCodec.Name():    GzipCodec,
+		DeflateCodec.Name(): DeflateCodec,
+	}
+)
+
+// RegisterCodec makes a codec available for automatic decompression on download.
+// 注册编解码器, 使其可用于下载时自动解压.
+func RegisterCodec(c Codec) {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+	codecs[c.Name()] = c
+}
+
+func lookupCodec(name string) (Codec, bool) {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+	c, ok := codecs[name]
+	return c, ok
+}
+
+// WithCompression enables compression of uploads of at least minSize bytes with codec.
+// Already-compressed MIME types (images, video, archives ...) and uploads above the large-upload
+// threshold of the smart upload functions, which are chunked, are uploaded as-is.
+// 对不小于 minSize 字节的上传启用 codec 压缩, 已压缩的 MIME 类型 (图片、视频、归档等) 以及超过智能上传
+// 分片阈值的上传 (走分片上传) 原样上传.
+func WithCompression(codec Codec, minSize int64) Option {
+	return func(s *SeaweedFSService) {
+		if codec != nil {
+			RegisterCodec(codec)
+			s.compression = codec
+			s.compressMinSize = minSize
+		}
+	}
+}
+
+// compressibleType reports whether content of the given MIME type is worth compressing.
+// 判断给定 MIME 类型的内容是否值得压缩.
+func compressibleType(contentType string) bool {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mt = strings.ToLower(strings.TrimSpace(contentType))
+	}
+
+	switch mt {
+	case "image/svg+xml", "image/bmp", "image/x-icon", "audio/wave", "audio/wav":
+		return true
+	case "application/zip", "application/gzip", "application/x-gzip", "application/x-bzip2",
+		"application/x-xz", "application/zstd", "application/x-rar-compressed",
+		"application/x-7z-compressed", "application/pdf", "application/vnd.ms-fontobject",
+		"font/woff", "font/woff2":
+		return false
+	}
+
+	for _, prefix := range []string{"image/", "video/", "audio/"} {
+		if strings.HasPrefix(mt, prefix) {
+			return false
+		}
+	}
+	return true
+}
+
+// maybeCompress decides whether an upload should be compressed. It sniffs the content type
+// when none is given and returns the (possibly buffered) reader to continue with.
+// 判断上传是否需要压缩, 未指定 Content-Type 时进行嗅探, 并返回后续应使用的 Reader.
+func (s *SeaweedFSService) maybeCompress(r io.Reader, size int64, headers map[string]string) (io.Reader, bool) {
+	if s.compression == nil || size < s.compressMinSize {
+		return r, false
+	}
+	if _, ok := headers["Content-Encoding"]; ok {
+		return r, false
+	}
+
+	contentType := headers["Content-Type"]
+	if contentType == "" {
+		br := bufio.NewReaderSize(r, 512)
+		head, _ := br.Peek(512)
+		contentType = http.DetectContentType(head)
+		// Pin the sniffed type so the filer does not sniff the compressed bytes.
+		headers["Content-Type"] = contentType
+		r = br
+	}
+	return r, compressibleType(contentType)
+}
+
+// uploadCompressed streams r through the configured codec and records the codec and original size as tags.
+// 通过配置的编解码器流式压缩 r 上传, 并将编解码器名称和原始大小记录为标签.
+func (s *SeaweedFSService) uploadCompressed(
+	ctx context.Context,
+	method UploadMethod,
+	dst string,
+	r io.Reader,
+	size int64,
+	opts map[string]string,
+	headers map[string]string,
+	progress ProgressFunc,
+) error {
+	pr, pw := io.Pipe()
+	go func() {
+		cw, err := s.compression.NewWriter(pw)
+		if err == nil {
+			_, err = io.Copy(cw, r)
+			if cerr := cw.Close(); err == nil {
+				err = cerr
+			}
+		}
+		pw.CloseWithError(err)
+	}()
+	// Unblock the compressing goroutine if the upload stops reading early.
+	defer pr.Close()
+
+	h := make(map[string]string, len(headers)+2)
+	for k, v := range headers {
+		h[k] = v
+	}
+	h["Seaweed-"+compressTagCodec] = s.compression.Name()
+	h["Seaweed-"+compressTagSize] = strconv.FormatInt(size, 10)
+
+	// The caller has reserved quota for the original size.
+	return s.uploadWithOptions(ctx, method, dst, pr, opts, h, progress)
+}
+
+// decodeBody wraps a download body with the decompressor recorded in its tags, if any.
+// 如果标签记录了压缩编解码器, 使用对应解压器包装下载内容.
+func decodeBody(rc io.ReadCloser, hdr http.Header) (io.ReadCloser, http.Header, error) {
+	name := hdr.Get("Seaweed-" + compressTagCodec)
+	if name == "" {
+		return rc, hdr, nil
+	}
+
+	codec, ok := lookupCodec(name)
+	if !ok {
+		rc.Close()
+		return nil, nil, fmt.Errorf("unknown compression codec %q", name)
+	}
+	dr, err := codec.NewReader(rc)
+	if err != nil {
+		rc.Close()
+		return nil, nil, err
+	}
+
+	out := hdr.Clone()
+	out.Del("Content-Length")
+	if size := hdr.Get("Seaweed-" + compressTagSize); size != "" {
+		out.Set("Content-Length", size)
+	}
+	return &decodedBody{Reader: dr, dr: dr, src: rc}, out, nil
+}
+
+// decodedBody closes both the decompressor and the underlying response body.
+type decodedBody struct {
+	io.Reader
+	dr  io.Closer
+	src io.Closer
+}
+
+func (b *decodedBody) Close() error {
+	b.dr.Close()
+	return b.src.Close()
+}
+
+// isCompressed reports whether tags mark the file as compressed by the SDK. 判断标签是否标记文件已被 SDK 压缩.
+func isCompressed(tags FileTags) bool {
+	return tags[compressTagCodec] != ""
+}
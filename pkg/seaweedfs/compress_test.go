@@ -0,0 +1,97 @@
+package seaweedfs
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestCompressRoundTrip(t *testing.T) {
+	f, srv := newFakeFiler(t)
+	s := NewSeaweedFSService(srv.URL, WithCompression(GzipCodec, 10))
+	ctx := context.Background()
+
+	plain := []byte(strings.Repeat("seaweedfs compresses well ", 200))
+	if err := s.UploadReaderSmart(ctx, UploadMethodPut, "/c/text", bytes.NewReader(plain), int64(len(plain)), 1<<20, 0,
+		nil, map[string]string{"Content-Type": "text/plain"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	stored, _ := f.file("/c/text")
+	if len(stored) >= len(plain) {
+		t.Fatalf("stored %d bytes for %d bytes of text", len(stored), len(plain))
+	}
+
+	rc, hdr, err := s.Download(ctx, "/c/text", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := readAllClose(t, rc); !bytes.Equal(got, plain) {
+		t.Fatal("round trip mismatch")
+	}
+	if cl := hdr.Get("Content-Length"); cl != fmt.Sprint(len(plain)) {
+		t.Fatalf("Content-Length: got %s, want %d", cl, len(plain))
+	}
+
+	// Already-compressed types and data above the large-upload threshold are stored as-is.
+	img := bytes.Repeat([]byte{0}, 100)
+	if err := s.UploadReaderSmart(ctx, UploadMethodPut, "/c/img", bytes.NewReader(img), 100, 1<<20, 0,
+		nil, map[string]string{"Content-Type": "image/png"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.UploadReaderSmart(ctx, UploadMethodPut, "/c/large", bytes.NewReader(plain), int64(len(plain)), 100, 1000,
+		nil, map[string]string{"Content-Type": "text/plain"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []string{"/c/img", "/c/large"} {
+		tags, err := s.GetTags(ctx, p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if isCompressed(tags) {
+			t.Fatalf("%s was compressed", p)
+		}
+	}
+}
+
+func TestCompressDownloadRange(t *testing.T) {
+	f, srv := newFakeFiler(t)
+	s := NewSeaweedFSService(srv.URL, WithCompression(GzipCodec, 10))
+	ctx := context.Background()
+
+	plain := []byte(strings.Repeat("0123456789", 100))
+	if err := s.UploadReaderSmart(ctx, UploadMethodPut, "/c/r", bytes.NewReader(plain), int64(len(plain)), 1<<20, 0,
+		nil, map[string]string{"Content-Type": "text/plain"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	stored, _ := f.file("/c/r")
+
+	// The last range starts past the compressed length but inside the original bytes.
+	for _, r := range [][2]int64{{0, 9}, {95, 104}, {int64(len(stored)) + 10, -1}, {990, 5000}} {
+		rc, hdr, status, err := s.DownloadRange(ctx, "/c/r", r[0], r[1], nil)
+		if err != nil {
+			t.Fatalf("range %v: %v", r, err)
+		}
+		end := r[1]
+		if end < 0 || end >= int64(len(plain)) {
+			end = int64(len(plain)) - 1
+		}
+		if got := readAllClose(t, rc); !bytes.Equal(got, plain[r[0]:end+1]) {
+			t.Fatalf("range %v: got %q", r, got)
+		}
+		if status != 206 {
+			t.Fatalf("range %v: status %d", r, status)
+		}
+		if want := fmt.Sprintf("bytes %d-%d/%d", r[0], end, len(plain)); hdr.Get("Content-Range") != want {
+			t.Fatalf("range %v: Content-Range %q, want %q", r, hdr.Get("Content-Range"), want)
+		}
+		if want := fmt.Sprint(end - r[0] + 1); hdr.Get("Content-Length") != want {
+			t.Fatalf("range %v: Content-Length %q, want %s", r, hdr.Get("Content-Length"), want)
+		}
+	}
+
+	if _, _, _, err := s.DownloadRange(ctx, "/c/r", int64(len(plain)), -1, nil); err == nil {
+		t.Fatal("range past the end accepted")
+	}
+}
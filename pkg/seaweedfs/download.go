@@ -10,19 +10,27 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"strconv"
 	"sync"
 
 	"github.com/GoFurry/seaweedfs-sdk-go/internal/util"
 )
 
-// Download downloads a file from SeaweedFS with the default options. 使用默认选项从 SeaweedFS 下载文件.
+// Download downloads a file from SeaweedFS with the default options.
+// Files compressed by the SDK are decompressed transparently.
+// 使用默认选项从 SeaweedFS 下载文件, SDK 压缩的文件会被自动解压.
 func (s *SeaweedFSService) Download(ctx context.Context, p string, progress ProgressFunc) (io.ReadCloser, http.Header, error) {
 	// Delegate to DownloadWithOptions without query or headers
 	rc, header, _, err := s.DownloadWithOptions(ctx, p, nil, nil, progress)
-	return rc, header, err
+	if err != nil {
+		return nil, nil, err
+	}
+	return decodeBody(rc, header)
 }
 
-// DownloadWithOptions downloads a file with custom query parameters and headers. 使用自定义查询参数和请求头下载文件.
+// DownloadWithOptions downloads a file with custom query parameters and headers.
+// The body is returned as stored, without decompression.
+// 使用自定义查询参数和请求头下载文件, 返回存储的原始内容, 不做解压.
 func (s *SeaweedFSService) DownloadWithOptions(
 	ctx context.Context,
 	p string,
@@ -56,7 +64,7 @@ func (s *SeaweedFSService) DownloadWithOptions(
 	}
 
 	// Execute request
-	resp, err := s.client.Do(req)
+	resp, err := s.do(req)
 	if err != nil {
 		return nil, nil, 0, err
 	}
@@ -72,7 +80,7 @@ func (s *SeaweedFSService) DownloadWithOptions(
 		b, _ := io.ReadAll(resp.Body)
 		resp.Body.Close()
 		return nil, nil, resp.StatusCode,
-			fmt.Errorf("download failed: %s %s", resp.Status, string(b))
+			&StatusError{Op: "download", Code: resp.StatusCode, Status: resp.Status, Body: string(b)}
 	}
 
 	// Callback when finished
@@ -84,7 +92,9 @@ func (s *SeaweedFSService) DownloadWithOptions(
 	return resp.Body, resp.Header, resp.StatusCode, nil
 }
 
-// DownloadRange downloads a specific byte range [start, end] from a file. 下载文件的指定字节范围 [start, end].
+// DownloadRange downloads a specific byte range [start, end] from a file. For files compressed by the
+// SDK the range refers to the original bytes.
+// 下载文件的指定字节范围 [start, end], 对 SDK 压缩的文件, 范围指原始字节.
 func (s *SeaweedFSService) DownloadRange(
 	ctx context.Context,
 	p string,
@@ -100,6 +110,27 @@ func (s *SeaweedFSService) DownloadRange(
 		return nil, nil, 0, fmt.Errorf("invalid range: end < start")
 	}
 
+	// Ranges of compressed files refer to decompressed bytes, so check the tags before requesting
+	// any stored bytes.
+	tags, err := s.GetTags(ctx, p)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	if isCompressed(tags) {
+		return s.downloadDecodedRange(ctx, p, start, end, tags, progress)
+	}
+	return s.downloadStoredRange(ctx, p, start, end, progress)
+}
+
+// downloadStoredRange requests the byte range [start, end] of the stored bytes, as DownloadRange
+// does for uncompressed files. 请求存储字节的范围 [start, end], 与 DownloadRange 对未压缩文件的处理相同.
+func (s *SeaweedFSService) downloadStoredRange(
+	ctx context.Context,
+	p string,
+	start, end int64,
+	progress ProgressFunc,
+) (io.ReadCloser, http.Header, int, error) {
+
 	// Build HTTP Range header value
 	rangeValue := ""
 	if end >= 0 {
@@ -126,6 +157,48 @@ func (s *SeaweedFSService) DownloadRange(
 	return rc, hdr, status, nil
 }
 
+// downloadDecodedRange serves a range of a compressed file by decompressing from the start and skipping.
+// The range is checked against the original size recorded in tags.
+// 通过从头解压并跳过前缀的方式读取压缩文件的指定范围, 并根据 tags 中记录的原始大小校验范围.
+func (s *SeaweedFSService) downloadDecodedRange(
+	ctx context.Context,
+	p string,
+	start, end int64,
+	tags FileTags,
+	progress ProgressFunc,
+) (io.ReadCloser, http.Header, int, error) {
+
+	size, err := strconv.ParseInt(tags[compressTagSize], 10, 64)
+	if err != nil || size < 0 {
+		return nil, nil, 0, fmt.Errorf("download range %s: invalid original size %q", p, tags[compressTagSize])
+	}
+	if end < 0 || end >= size {
+		end = size - 1
+	}
+	if start > end {
+		return nil, nil, http.StatusRequestedRangeNotSatisfiable, fmt.Errorf("invalid range: start beyond end of file")
+	}
+
+	rc, hdr, err := s.Download(ctx, p, progress)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	if _, err := io.CopyN(io.Discard, rc, start); err != nil {
+		rc.Close()
+		return nil, nil, 0, err
+	}
+
+	hdr.Set("Content-Length", strconv.FormatInt(end-start+1, 10))
+	hdr.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, size))
+	return &limitedBody{Reader: io.LimitReader(rc, end-start+1), Closer: rc}, hdr, http.StatusPartialContent, nil
+}
+
+// limitedBody pairs a limited reader with the Closer of the underlying body.
+type limitedBody struct {
+	io.Reader
+	io.Closer
+}
+
 // DownloadResume resumes downloading a file from a given offset. 从指定偏移量继续下载文件.
 func (s *SeaweedFSService) DownloadResume(
 	ctx context.Context,
@@ -136,7 +209,12 @@ func (s *SeaweedFSService) DownloadResume(
 
 	// Offset <= 0 means full download
 	if offset <= 0 {
-		return s.DownloadWithOptions(ctx, p, nil, nil, progress)
+		rc, hdr, status, err := s.DownloadWithOptions(ctx, p, nil, nil, progress)
+		if err != nil {
+			return nil, nil, status, err
+		}
+		rc, hdr, err = decodeBody(rc, hdr)
+		return rc, hdr, status, err
 	}
 
 	return s.DownloadRange(ctx, p, offset, -1, progress)
@@ -168,15 +246,16 @@ func (s *SeaweedFSService) DownloadConcurrent(
 	}
 
 	// Fetch remote file metadata to get size
-	stat, err := s.Stat(ctx, remotePath, false)
+	stat, err := s.Stat(ctx, remotePath, true)
 	if err != nil {
 		result[dstPath] = fmt.Errorf("stat failed: %w", err)
 		return result
 	}
 	size := stat.Size
 
-	// Fallback to sequential download for small files
-	if chunkCount <= 1 || size < int64(chunkCount*5<<20) {
+	// Fallback to sequential download for small files and for compressed files,
+	// whose stored size does not match the decompressed content.
+	if chunkCount <= 1 || size < int64(chunkCount*5<<20) || isCompressed(stat.Tags) {
 		select {
 		case <-ctx.Done():
 			result[dstPath] = ctx.Err()
@@ -0,0 +1,500 @@
+// Package seaweedfs provides a Go client for interacting with SeaweedFS.
+// It includes client-side envelope encryption for uploads and downloads.
+// 提供 SeaweedFS 的 Go 客户端, 包括上传与下载的客户端信封加密.
+package seaweedfs
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+// Encryption metadata is stored as file tags (Seaweed- prefixed headers).
+// 加密元数据以文件标签 (Seaweed- 前缀头) 的形式存储.
+const (
+	encTagAlg     = "Enc-Alg"
+	encTagKey     = "Enc-Key"
+	encTagKeyID   = "Enc-Key-Id"
+	encTagNonce   = "Enc-Nonce"
+	encTagSegment = "Enc-Segment"
+	encTagSize    = "Enc-Size"
+
+	encAlgorithm          = "AES256-GCM-SEG"
+	defaultEncSegmentSize = 64 << 10
+)
+
+// ErrNotEncrypted is returned when a file has no encryption metadata. 文件缺少加密元数据时返回.
+var ErrNotEncrypted = errors.New("file is not encrypted")
+
+// KeyProvider wraps and unwraps per-file data keys (e.g. backed by a KMS).
+// 包装与解包每个文件的数据密钥 (例如由 KMS 提供).
+type KeyProvider interface {
+	WrapKey(ctx context.Context, dataKey []byte) (wrapped []byte, keyID string, err error)
+	UnwrapKey(ctx context.Context, wrapped []byte, keyID string) ([]byte, error)
+}
+
+// StaticKeyProvider wraps data keys with a fixed 256-bit key-encryption key using AES-GCM.
+// 使用固定的 256 位密钥加密密钥 (AES-GCM) 包装数据密钥.
+type StaticKeyProvider struct {
+	ID   string
+	aead cipher.AEAD
+}
+
+// NewStaticKeyProvider creates a StaticKeyProvider; kek must be 32 bytes.
+// 创建 StaticKeyProvider, kek 必须为 32 字节.
+func NewStaticKeyProvider(id string, kek []byte) (*StaticKeyProvider, error) {
+	if len(kek) != 32 {
+		return nil, fmt.Errorf("key-encryption key must be 32 bytes, got %d", len(kek))
+	}
+	aead, err := newGCM(kek)
+	if err != nil {
+		return nil, err
+	}
+	return &StaticKeyProvider{ID: id, aead: aead}, nil
+}
+
+// WrapKey implements KeyProvider. 实现 KeyProvider 接口.
+func (p *StaticKeyProvider) WrapKey(_ context.Context, dataKey []byte) ([]byte, string, error) {
+	nonce := make([]byte, p.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, "", err
+	}
+	return p.aead.Seal(nonce, nonce, dataKey, []byte(p.ID)), p.ID, nil
+}
+
+// UnwrapKey implements KeyProvider. 实现 KeyProvider 接口.
+func (p *StaticKeyProvider) UnwrapKey(_ context.Context, wrapped []byte, keyID string) ([]byte, error) {
+	if keyID != p.ID {
+		return nil, fmt.Errorf("unknown key id %q", keyID)
+	}
+	ns := p.aead.NonceSize()
+	if len(wrapped) < ns {
+		return nil, errors.New("wrapped key too short")
+	}
+	return p.aead.Open(nil, wrapped[:ns], wrapped[ns:], []byte(keyID))
+}
+
+// Encryptor encrypts file contents with AES-256-GCM before upload and decrypts them on download.
+// Data is sealed in fixed-size authenticated segments so that range reads stay efficient.
+// 在上传前使用 AES-256-GCM 加密文件内容并在下载时解密, 数据按固定大小分段认证加密, 保证范围读取高效.
+type Encryptor struct {
+	s           *SeaweedFSService
+	keys        KeyProvider
+	SegmentSize int // Plaintext bytes per segment / 每段明文字节数
+}
+
+// NewEncryptor creates an Encryptor for the service using keys to wrap data keys.
+// 创建 Encryptor, 使用 keys 包装数据密钥.
+func NewEncryptor(s *SeaweedFSService, keys KeyProvider) *Encryptor {
+	return &Encryptor{s: s, keys: keys, SegmentSize: defaultEncSegmentSize}
+}
+
+// encMeta holds the per-file encryption parameters. 每个文件的加密参数.
+type encMeta struct {
+	aead    cipher.AEAD
+	nonce   []byte
+	segment int64
+	size    int64 // plaintext size / 明文大小
+}
+
+func (m *encMeta) segments() int64 {
+	if m.size == 0 {
+		return 1
+	}
+	return (m.size + m.segment - 1) / m.segment
+}
+
+func (m *encMeta) cipherSize() int64 {
+	return m.size + m.segments()*int64(m.aead.Overhead())
+}
+
+// seal encrypts one segment; the nonce and additional data bind its index and whether it is final.
+func (m *encMeta) seal(dst, plain []byte, idx int64) []byte {
+	final := idx == m.segments()-1
+	return m.aead.Seal(dst, m.segmentNonce(idx), plain, segmentAAD(idx, final))
+}
+
+func (m *encMeta) open(dst, sealed []byte, idx int64) ([]byte, error) {
+	final := idx == m.segments()-1
+	return m.aead.Open(dst, m.segmentNonce(idx), sealed, segmentAAD(idx, final))
+}
+
+func (m *encMeta) segmentNonce(idx int64) []byte {
+	n := make([]byte, len(m.nonce))
+	copy(n, m.nonce)
+	tail := n[len(n)-8:]
+	binary.BigEndian.PutUint64(tail, binary.BigEndian.Uint64(tail)^uint64(idx))
+	return n
+}
+
+func segmentAAD(idx int64, final bool) []byte {
+	aad := make([]byte, 9)
+	binary.BigEndian.PutUint64(aad, uint64(idx))
+	if final {
+		aad[8] = 1
+	}
+	return aad
+}
+
+// UploadReaderSmart encrypts r and uploads it, choosing normal or chunked upload by plaintext size.
+// 加密 r 并上传, 根据明文大小选择普通或分片上传.
+func (e *Encryptor) UploadReaderSmart(
+	ctx context.Context,
+	method UploadMethod, // HTTP method / HTTP 方法
+	dst string, // Destination path / 目标路径
+	r io.Reader, // Plaintext reader / 明文数据源
+	size int64, // Plaintext size / 明文大小
+	largeThreshold int64, // Threshold for large upload / 大文件阈值
+	chunkSize int64, // Chunk size / 分片大小
+	opts map[string]string, // Optional query parameters / 可选查询参数
+	headers map[string]string, // Optional HTTP headers / 可选 HTTP 头
+	progress ProgressFunc, // Callback for progress / 进度回调
+) error {
+	if size <= largeThreshold {
+		er, h, err := e.prepare(ctx, r, size, headers)
+		if err != nil {
+			return err
+		}
+		return e.s.UploadWithOptions(ctx, method, dst, er, opts, h, progress)
+	}
+	return e.UploadLarge(ctx, method, dst, r, size, chunkSize, opts, headers,
+		&UploadLargeOptions{
+			MaxRetry:  3,
+			UseOffset: true,
+		},
+		progress,
+	)
+}
+
+// UploadLarge encrypts r and uploads it in chunks. size is the plaintext size.
+// 加密 r 并分片上传, size 为明文大小.
+func (e *Encryptor) UploadLarge(
+	ctx context.Context,
+	method UploadMethod, // HTTP method / HTTP 方法
+	dst string, // Destination path / 目标路径
+	r io.Reader, // Plaintext reader / 明文数据源
+	size int64, // Plaintext size / 明文大小
+	chunkSize int64, // Size of each chunk / 每个分片大小
+	opts map[string]string, // Optional query parameters / 可选查询参数
+	headers map[string]string, // Optional HTTP headers / 可选 HTTP 头
+	largeOpt *UploadLargeOptions, // Options for large upload / 大文件上传选项
+	progress ProgressFunc, // Callback for progress / 进度回调
+) error {
+	er, h, err := e.prepare(ctx, r, size, headers)
+	if err != nil {
+		return err
+	}
+	return e.s.UploadLarge(ctx, method, dst, er, er.meta.cipherSize(), chunkSize, opts, h, largeOpt, progress)
+}
+
+// prepare generates a data key, wraps it and returns the encrypting reader plus headers carrying the metadata.
+// 生成并包装数据密钥, 返回加密 Reader 及携带元数据的请求头.
+func (e *Encryptor) prepare(ctx context.Context, r io.Reader, size int64, headers map[string]string) (*encryptReader, map[string]string, error) {
+	if size < 0 {
+		return nil, nil, errors.New("encrypted upload requires a known size")
+	}
+
+	segment := e.SegmentSize
+	if segment <= 0 {
+		segment = defaultEncSegmentSize
+	}
+
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, nil, err
+	}
+	wrapped, keyID, err := e.keys.WrapKey(ctx, dataKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("wrap data key: %w", err)
+	}
+
+	aead, err := newGCM(dataKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, nil, err
+	}
+
+	meta := &encMeta{aead: aead, nonce: nonce, segment: int64(segment), size: size}
+
+	h := make(map[string]string, len(headers)+6)
+	for k, v := range headers {
+		h[k] = v
+	}
+	h["Seaweed-"+encTagAlg] = encAlgorithm
+	h["Seaweed-"+encTagKey] = base64.StdEncoding.EncodeToString(wrapped)
+	h["Seaweed-"+encTagKeyID] = keyID
+	h["Seaweed-"+encTagNonce] = base64.StdEncoding.EncodeToString(nonce)
+	h["Seaweed-"+encTagSegment] = strconv.Itoa(segment)
+	h["Seaweed-"+encTagSize] = strconv.FormatInt(size, 10)
+
+	return &encryptReader{src: r, meta: meta}, h, nil
+}
+
+// Download downloads and decrypts a whole file. 下载并解密整个文件.
+func (e *Encryptor) Download(ctx context.Context, p string, progress ProgressFunc) (io.ReadCloser, http.Header, error) {
+	rc, hdr, _, err := e.s.DownloadWithOptions(ctx, p, nil, nil, progress)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	meta, err := e.metaFromHeader(ctx, p, hdr)
+	if err != nil {
+		rc.Close()
+		return nil, nil, err
+	}
+
+	out := hdr.Clone()
+	out.Set("Content-Length", strconv.FormatInt(meta.size, 10))
+	return &decryptReader{src: rc, meta: meta, limit: meta.size}, out, nil
+}
+
+// DownloadRange downloads and decrypts the plaintext byte range [start, end]; end < 0 means until EOF.
+// Only the segments covering the range are fetched.
+// 下载并解密明文字节范围 [start, end], end < 0 表示直到文件末尾, 只获取覆盖该范围的分段.
+func (e *Encryptor) DownloadRange(
+	ctx context.Context,
+	p string,
+	start, end int64,
+	progress ProgressFunc,
+) (io.ReadCloser, http.Header, int, error) {
+
+	if start < 0 {
+		return nil, nil, 0, fmt.Errorf("invalid range start")
+	}
+	if end >= 0 && end < start {
+		return nil, nil, 0, fmt.Errorf("invalid range: end < start")
+	}
+
+	// Range reads need the metadata before the request to map plaintext to ciphertext offsets.
+	tags, err := e.s.GetTags(ctx, p)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	meta, err := e.metaFromTags(ctx, tags)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	if end < 0 || end >= meta.size {
+		end = meta.size - 1
+	}
+	if start > end {
+		return nil, nil, 0, fmt.Errorf("invalid range: start beyond end of file")
+	}
+
+	sealed := meta.segment + int64(meta.aead.Overhead())
+	first := start / meta.segment
+	last := end / meta.segment
+	cStart := first * sealed
+	cEnd := (last+1)*sealed - 1
+	if cEnd >= meta.cipherSize() {
+		cEnd = meta.cipherSize() - 1
+	}
+
+	// The tags were read above; an encrypted file is never compressed by the SDK.
+	rc, hdr, status, err := e.s.downloadStoredRange(ctx, p, cStart, cEnd, progress)
+	if err != nil {
+		return nil, nil, status, err
+	}
+
+	// SeaweedFS may ignore the range and return the full body.
+	if status == http.StatusOK && cStart > 0 {
+		if _, err := io.CopyN(io.Discard, rc, cStart); err != nil {
+			rc.Close()
+			return nil, nil, status, err
+		}
+	}
+
+	out := hdr.Clone()
+	out.Set("Content-Length", strconv.FormatInt(end-start+1, 10))
+	out.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, meta.size))
+
+	return &decryptReader{
+		src:   rc,
+		meta:  meta,
+		idx:   first,
+		skip:  start - first*meta.segment,
+		limit: end - start + 1,
+	}, out, http.StatusPartialContent, nil
+}
+
+func (e *Encryptor) metaFromHeader(ctx context.Context, p string, hdr http.Header) (*encMeta, error) {
+	tags := make(FileTags)
+	for _, k := range []string{encTagAlg, encTagKey, encTagKeyID, encTagNonce, encTagSegment, encTagSize} {
+		if v := hdr.Get("Seaweed-" + k); v != "" {
+			tags[k] = v
+		}
+	}
+	// Fall back to a HEAD request when the GET response omits the tags.
+	if tags[encTagAlg] == "" {
+		var err error
+		if tags, err = e.s.GetTags(ctx, p); err != nil {
+			return nil, err
+		}
+	}
+	return e.metaFromTags(ctx, tags)
+}
+
+func (e *Encryptor) metaFromTags(ctx context.Context, tags FileTags) (*encMeta, error) {
+	if tags[encTagAlg] == "" {
+		return nil, ErrNotEncrypted
+	}
+	if tags[encTagAlg] != encAlgorithm {
+		return nil, fmt.Errorf("unsupported encryption algorithm %q", tags[encTagAlg])
+	}
+
+	wrapped, err := base64.StdEncoding.DecodeString(tags[encTagKey])
+	if err != nil {
+		return nil, fmt.Errorf("invalid wrapped key: %w", err)
+	}
+	nonce, err := base64.StdEncoding.DecodeString(tags[encTagNonce])
+	if err != nil {
+		return nil, fmt.Errorf("invalid nonce: %w", err)
+	}
+	segment, err := strconv.ParseInt(tags[encTagSegment], 10, 64)
+	if err != nil || segment <= 0 {
+		return nil, fmt.Errorf("invalid segment size %q", tags[encTagSegment])
+	}
+	size, err := strconv.ParseInt(tags[encTagSize], 10, 64)
+	if err != nil || size < 0 {
+		return nil, fmt.Errorf("invalid plaintext size %q", tags[encTagSize])
+	}
+
+	dataKey, err := e.keys.UnwrapKey(ctx, wrapped, tags[encTagKeyID])
+	if err != nil {
+		return nil, fmt.Errorf("unwrap data key: %w", err)
+	}
+	aead, err := newGCM(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(nonce) != aead.NonceSize() {
+		return nil, fmt.Errorf("invalid nonce length %d", len(nonce))
+	}
+
+	return &encMeta{aead: aead, nonce: nonce, segment: segment, size: size}, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// encryptReader turns a plaintext stream into a stream of sealed segments.
+// 将明文流转换为认证加密分段流.
+type encryptReader struct {
+	src  io.Reader
+	meta *encMeta
+	idx  int64
+	read int64
+	buf  []byte
+	out  []byte
+}
+
+func (r *encryptReader) Read(p []byte) (int, error) {
+	for len(r.out) == 0 {
+		if r.idx >= r.meta.segments() {
+			return 0, io.EOF
+		}
+
+		n := r.meta.segment
+		if rem := r.meta.size - r.read; rem < n {
+			n = rem
+		}
+		if r.buf == nil {
+			r.buf = make([]byte, r.meta.segment, r.meta.segment+int64(r.meta.aead.Overhead()))
+		}
+		if _, err := io.ReadFull(r.src, r.buf[:n]); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return 0, err
+		}
+
+		r.out = r.meta.seal(r.buf[:0], r.buf[:n], r.idx)
+		r.read += n
+		r.idx++
+	}
+
+	n := copy(p, r.out)
+	r.out = r.out[n:]
+	return n, nil
+}
+
+// decryptReader opens sealed segments starting at idx and yields limit plaintext bytes after skip.
+// 从 idx 开始解密分段, 跳过 skip 字节后输出 limit 字节明文.
+type decryptReader struct {
+	src   io.ReadCloser
+	meta  *encMeta
+	idx   int64
+	skip  int64
+	limit int64
+	buf   []byte
+	out   []byte
+}
+
+func (r *decryptReader) Read(p []byte) (int, error) {
+	if r.limit <= 0 {
+		return 0, io.EOF
+	}
+
+	for len(r.out) == 0 {
+		if r.idx >= r.meta.segments() {
+			return 0, io.ErrUnexpectedEOF
+		}
+
+		plain := r.meta.segment
+		if rem := r.meta.size - r.idx*r.meta.segment; rem < plain {
+			plain = rem
+		}
+		sealed := plain + int64(r.meta.aead.Overhead())
+		if r.buf == nil {
+			r.buf = make([]byte, r.meta.segment+int64(r.meta.aead.Overhead()))
+		}
+		if _, err := io.ReadFull(r.src, r.buf[:sealed]); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return 0, err
+		}
+
+		out, err := r.meta.open(r.buf[:0], r.buf[:sealed], r.idx)
+		if err != nil {
+			return 0, fmt.Errorf("decrypt segment %d: %w", r.idx, err)
+		}
+		r.idx++
+
+		if r.skip > 0 {
+			s := min(r.skip, int64(len(out)))
+			out = out[s:]
+			r.skip -= s
+		}
+		r.out = out
+	}
+
+	if int64(len(p)) > r.limit {
+		p = p[:r.limit]
+	}
+	n := copy(p, r.out)
+	r.out = r.out[n:]
+	r.limit -= int64(n)
+	return n, nil
+}
+
+func (r *decryptReader) Close() error {
+	return r.src.Close()
+}
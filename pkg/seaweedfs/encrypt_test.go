@@ -0,0 +1,176 @@
+package seaweedfs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+)
+
+func newTestEncryptor(t *testing.T, s *SeaweedFSService, kek byte) *Encryptor {
+	t.Helper()
+	keys, err := NewStaticKeyProvider("k1", bytes.Repeat([]byte{kek}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := NewEncryptor(s, keys)
+	e.SegmentSize = 16
+	return e
+}
+
+func testPlaintext(n int) []byte {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = byte(i*7 + 3)
+	}
+	return b
+}
+
+func readAllClose(t *testing.T, rc io.ReadCloser) []byte {
+	t.Helper()
+	defer rc.Close()
+	b, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestEncryptRoundTrip(t *testing.T) {
+	f, srv := newFakeFiler(t)
+	e := newTestEncryptor(t, NewSeaweedFSService(srv.URL), 1)
+	ctx := context.Background()
+
+	// Empty, partial, exactly one, exact multiple and trailing partial segments.
+	for _, n := range []int{0, 5, 16, 48, 50} {
+		for _, large := range []bool{false, true} {
+			name := fmt.Sprintf("%d-%v", n, large)
+			t.Run(name, func(t *testing.T) {
+				p := "/enc/" + name
+				plain := testPlaintext(n)
+				threshold := int64(1 << 20)
+				if large {
+					threshold = 0
+				}
+				err := e.UploadReaderSmart(ctx, UploadMethodPut, p, bytes.NewReader(plain), int64(n), threshold, 20, nil, nil, nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				stored, _ := f.file(p)
+				if n > 0 && bytes.Contains(stored, plain) {
+					t.Fatal("plaintext stored in the clear")
+				}
+				// One GCM tag per segment, and one segment for the empty file.
+				segs := max((n+15)/16, 1)
+				if len(stored) != n+16*segs {
+					t.Fatalf("ciphertext size: got %d, want %d", len(stored), n+16*segs)
+				}
+
+				rc, hdr, err := e.Download(ctx, p, nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got := readAllClose(t, rc); !bytes.Equal(got, plain) {
+					t.Fatalf("round trip: got %x, want %x", got, plain)
+				}
+				if cl := hdr.Get("Content-Length"); cl != fmt.Sprint(n) {
+					t.Fatalf("Content-Length: got %s, want %d", cl, n)
+				}
+			})
+		}
+	}
+}
+
+func TestEncryptDownloadRange(t *testing.T) {
+	_, srv := newFakeFiler(t)
+	e := newTestEncryptor(t, NewSeaweedFSService(srv.URL), 1)
+	ctx := context.Background()
+
+	plain := testPlaintext(50)
+	if err := e.UploadReaderSmart(ctx, UploadMethodPut, "/enc/r", bytes.NewReader(plain), 50, 1<<20, 0, nil, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, r := range [][2]int64{{0, 0}, {3, 12}, {15, 16}, {10, 40}, {16, 31}, {32, 49}, {47, -1}, {20, 100}} {
+		rc, hdr, status, err := e.DownloadRange(ctx, "/enc/r", r[0], r[1], nil)
+		if err != nil {
+			t.Fatalf("range %v: %v", r, err)
+		}
+		end := r[1]
+		if end < 0 || end >= 50 {
+			end = 49
+		}
+		got := readAllClose(t, rc)
+		if !bytes.Equal(got, plain[r[0]:end+1]) {
+			t.Fatalf("range %v: got %x, want %x", r, got, plain[r[0]:end+1])
+		}
+		if want := fmt.Sprintf("bytes %d-%d/50", r[0], end); status != 206 || hdr.Get("Content-Range") != want {
+			t.Fatalf("range %v: got %d %q, want 206 %q", r, status, hdr.Get("Content-Range"), want)
+		}
+	}
+
+	if _, _, _, err := e.DownloadRange(ctx, "/enc/r", 50, -1, nil); err == nil {
+		t.Fatal("range past the end accepted")
+	}
+}
+
+func TestEncryptDetectsTampering(t *testing.T) {
+	f, srv := newFakeFiler(t)
+	s := NewSeaweedFSService(srv.URL)
+	e := newTestEncryptor(t, s, 1)
+	ctx := context.Background()
+
+	plain := testPlaintext(40)
+	upload := func() {
+		t.Helper()
+		if err := e.UploadReaderSmart(ctx, UploadMethodPut, "/enc/t", bytes.NewReader(plain), 40, 1<<20, 0, nil, nil, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	download := func() error {
+		rc, _, err := e.Download(ctx, "/enc/t", nil)
+		if err != nil {
+			return err
+		}
+		defer rc.Close()
+		_, err = io.ReadAll(rc)
+		return err
+	}
+	mutate := func(fn func([]byte) []byte) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.m["/enc/t"].data = fn(f.m["/enc/t"].data)
+	}
+
+	cases := map[string]func([]byte) []byte{
+		"flipped bit": func(b []byte) []byte { b[20] ^= 1; return b },
+		// Dropping the final segment must not look like a shorter file.
+		"truncated": func(b []byte) []byte { return b[:64] },
+		"swapped segments": func(b []byte) []byte {
+			return append(append(append([]byte{}, b[32:64]...), b[:32]...), b[64:]...)
+		},
+	}
+	for name, fn := range cases {
+		upload()
+		mutate(fn)
+		if err := download(); err == nil {
+			t.Fatalf("%s: tampered ciphertext decrypted", name)
+		}
+	}
+
+	upload()
+	other := newTestEncryptor(t, s, 2)
+	if _, _, err := other.Download(ctx, "/enc/t", nil); err == nil {
+		t.Fatal("wrong key-encryption key accepted")
+	}
+
+	if err := s.UploadWithOptions(ctx, UploadMethodPut, "/enc/plain", bytes.NewReader(plain), nil, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := e.Download(ctx, "/enc/plain", nil); !errors.Is(err, ErrNotEncrypted) {
+		t.Fatalf("plain file: got %v, want ErrNotEncrypted", err)
+	}
+}
@@ -0,0 +1,302 @@
+package seaweedfs
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// fakeEntry is a file or directory held by fakeFiler.
+type fakeEntry struct {
+	data  []byte
+	tags  map[string]string // Seaweed- headers, as the filer stores them in Extended
+	mtime time.Time
+	dir   bool
+	mode  uint32
+}
+
+// fakeFiler is an in-memory stand-in for the filer HTTP API. Like the real filer it is
+// last-writer-wins and ignores conditional request headers.
+type fakeFiler struct {
+	mu sync.Mutex
+	m  map[string]*fakeEntry
+
+	// hook, if set, is called with every request before it is served; returning true means the
+	// hook wrote the response itself, e.g. to inject a failure.
+	hook func(w http.ResponseWriter, r *http.Request) bool
+}
+
+// newFakeFiler starts a fake filer; the server is closed when the test ends.
+func newFakeFiler(t interface{ Cleanup(func()) }) (*fakeFiler, *httptest.Server) {
+	f := &fakeFiler{m: map[string]*fakeEntry{"/": {dir: true, tags: map[string]string{}}}}
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	return f, srv
+}
+
+// file returns the contents of p, or false if p is not a file.
+func (f *fakeFiler) file(p string) ([]byte, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	e := f.m[p]
+	if e == nil || e.dir {
+		return nil, false
+	}
+	return append([]byte(nil), e.data...), true
+}
+
+func (f *fakeFiler) mkparents(p string) {
+	for d := parentDir(p); d != "/"; d = parentDir(d) {
+		if _, ok := f.m[d]; !ok {
+			f.m[d] = &fakeEntry{dir: true, mtime: time.Now(), tags: map[string]string{}}
+		}
+	}
+}
+
+func parentDir(p string) string {
+	i := strings.LastIndex(strings.TrimRight(p, "/"), "/")
+	if i <= 0 {
+		return "/"
+	}
+	return p[:i]
+}
+
+func setTags(e *fakeEntry, h http.Header) {
+	for k, v := range h {
+		if strings.HasPrefix(k, "Seaweed-") {
+			e.tags[k] = v[0]
+		}
+	}
+}
+
+func (f *fakeFiler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if f.hook != nil && f.hook(w, r) {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	p := r.URL.Path
+	if p != "/" {
+		p = strings.TrimRight(p, "/")
+	}
+	q := r.URL.Query()
+	switch r.Method {
+	case http.MethodPut, http.MethodPost:
+		f.write(w, r, p, q)
+	case http.MethodDelete:
+		f.delete(w, p, q)
+	case http.MethodGet, http.MethodHead:
+		f.read(w, r, p, q)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (f *fakeFiler) write(w http.ResponseWriter, r *http.Request, p string, q map[string][]string) {
+	get := func(k string) string {
+		if v := q[k]; len(v) > 0 {
+			return v[0]
+		}
+		return ""
+	}
+
+	if _, ok := q["tagging"]; ok {
+		e := f.m[p]
+		if e == nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		setTags(e, r.Header)
+		return
+	}
+	if from := strings.TrimRight(get("mv.from"), "/"); from != "" {
+		if f.m[from] == nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		for k, e := range f.m {
+			if k == from || strings.HasPrefix(k, from+"/") {
+				delete(f.m, k)
+				f.m[p+strings.TrimPrefix(k, from)] = e
+			}
+		}
+		f.mkparents(p)
+		return
+	}
+	if from := get("cp.from"); from != "" {
+		e := f.m[from]
+		if e == nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		c := *e
+		c.data = append([]byte(nil), e.data...)
+		c.tags = map[string]string{}
+		for k, v := range e.tags {
+			c.tags[k] = v
+		}
+		c.mtime = time.Now()
+		f.m[p] = &c
+		f.mkparents(p)
+		return
+	}
+	if strings.HasSuffix(r.URL.Path, "/") {
+		f.m[p] = &fakeEntry{dir: true, mtime: time.Now(), tags: map[string]string{}}
+		f.mkparents(p)
+		return
+	}
+
+	b, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	e := f.m[p]
+	switch {
+	case get("op") == "append" && e != nil:
+		e.data = append(e.data, b...)
+	case get("offset") != "" && e != nil:
+		off, _ := strconv.Atoi(get("offset"))
+		e.data = append(e.data[:off], b...)
+	default:
+		e = &fakeEntry{data: b, tags: map[string]string{}}
+		f.m[p] = e
+	}
+	e.mtime = time.Now()
+	if m := get("mode"); m != "" {
+		v, _ := strconv.ParseUint(m, 8, 32)
+		e.mode = uint32(v)
+	}
+	setTags(e, r.Header)
+	f.mkparents(p)
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (f *fakeFiler) delete(w http.ResponseWriter, p string, q map[string][]string) {
+	e := f.m[p]
+	if e == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if keys, ok := q["tagging"]; ok {
+		if len(keys) == 0 || keys[0] == "" {
+			e.tags = map[string]string{}
+			return
+		}
+		for _, k := range strings.Split(keys[0], ",") {
+			delete(e.tags, http.CanonicalHeaderKey("Seaweed-"+k))
+		}
+		return
+	}
+	for k := range f.m {
+		if k == p || strings.HasPrefix(k, p+"/") {
+			delete(f.m, k)
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (f *fakeFiler) read(w http.ResponseWriter, r *http.Request, p string, q map[string][]string) {
+	e := f.m[p]
+	if e == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	for k, v := range e.tags {
+		w.Header().Set(k, v)
+	}
+	if v := q["metadata"]; len(v) > 0 && v[0] == "true" {
+		json.NewEncoder(w).Encode(f.meta(p, e))
+		return
+	}
+	if e.dir {
+		f.list(w, p, q)
+		return
+	}
+
+	data := e.data
+	if rg := strings.TrimPrefix(r.Header.Get("Range"), "bytes="); rg != "" {
+		parts := strings.SplitN(rg, "-", 2)
+		start, _ := strconv.Atoi(parts[0])
+		if start >= len(data) {
+			w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+			return
+		}
+		end := len(data) - 1
+		if parts[1] != "" {
+			end, _ = strconv.Atoi(parts[1])
+			end = min(end, len(data)-1)
+		}
+		w.WriteHeader(http.StatusPartialContent)
+		w.Write(data[start : end+1])
+		return
+	}
+	w.Write(data)
+}
+
+func (f *fakeFiler) list(w http.ResponseWriter, p string, q map[string][]string) {
+	get := func(k string) string {
+		if v := q[k]; len(v) > 0 {
+			return v[0]
+		}
+		return ""
+	}
+
+	var names []string
+	for k := range f.m {
+		if k != p && parentDir(k) == p {
+			names = append(names, k)
+		}
+	}
+	sort.Strings(names)
+
+	last, pattern := get("lastFileName"), get("namePattern")
+	limit, _ := strconv.Atoi(get("limit"))
+	entries := []map[string]any{}
+	lastName := ""
+	for _, k := range names {
+		base := path.Base(k)
+		if last != "" && base <= last {
+			continue
+		}
+		if pattern != "" {
+			if ok, _ := path.Match(pattern, base); !ok {
+				continue
+			}
+		}
+		if limit > 0 && len(entries) >= limit {
+			break
+		}
+		entries = append(entries, f.meta(k, f.m[k]))
+		lastName = base
+	}
+	json.NewEncoder(w).Encode(map[string]any{"Path": p, "Entries": entries, "LastFileName": lastName})
+}
+
+func (f *fakeFiler) meta(p string, e *fakeEntry) map[string]any {
+	mode := e.mode
+	if e.dir {
+		mode |= uint32(os.ModeDir)
+	}
+	ext := map[string][]byte{}
+	for k, v := range e.tags {
+		ext[k] = []byte(v)
+	}
+	return map[string]any{
+		"FullPath": p,
+		"Mtime":    e.mtime.Format(time.RFC3339),
+		"Crtime":   e.mtime.Format(time.RFC3339),
+		"Mode":     mode,
+		"FileSize": len(e.data),
+		"Extended": ext,
+	}
+}
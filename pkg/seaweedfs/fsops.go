@@ -6,8 +6,10 @@ package seaweedfs
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"net/http"
 	"net/url"
 	"os"
@@ -29,7 +31,7 @@ func (s *SeaweedFSService) Mkdir(ctx context.Context, dir string) error {
 
 	// SeaweedFS uses HTTP POST on the filer endpoint to create directories.
 	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, s.FilerEndpoint+dir, nil)
-	resp, err := s.client.Do(req)
+	resp, err := s.do(req)
 	if err != nil {
 		return err
 	}
@@ -37,15 +39,29 @@ func (s *SeaweedFSService) Mkdir(ctx context.Context, dir string) error {
 
 	// Any 4xx or 5xx status code is treated as a failure.
 	if resp.StatusCode >= 400 {
-		return fmt.Errorf("mkdir failed: %s", resp.Status)
+		return &StatusError{Op: "mkdir", Code: resp.StatusCode, Status: resp.Status}
 	}
 	return nil
 }
 
 // Delete removes a file or directory.
 // extra allows passing additional parameters compatible with SeaweedFS official API (e.g., recursive, skipChunkDeletion).
-// 删除文件或目录, extra 用于传递可选参数, 兼容官方 API (如 recursive, skipChunkDeletion)
+// When trash mode is enabled (see WithTrash), the entry is moved to the trash instead.
+// 删除文件或目录, extra 用于传递可选参数, 兼容官方 API (如 recursive, skipChunkDeletion).
+// 启用回收站模式 (见 WithTrash) 时, 条目会被移入回收站.
 func (s *SeaweedFSService) Delete(ctx context.Context, p string, extra map[string]string) error {
+	var err error
+	if s.trashDir != "" && !s.inTrash(p) {
+		err = s.moveToTrash(ctx, p, extra)
+	} else {
+		err = s.deleteEntry(ctx, p, extra)
+	}
+	return err
+}
+
+// deleteEntry removes a file or directory permanently, bypassing the trash.
+// 永久删除文件或目录, 不经过回收站.
+func (s *SeaweedFSService) deleteEntry(ctx context.Context, p string, extra map[string]string) error {
 	// Normalize the path to avoid unexpected filer behavior.
 	p = util.NormalizePath(p)
 
@@ -67,7 +83,7 @@ func (s *SeaweedFSService) Delete(ctx context.Context, p string, extra map[strin
 		return err
 	}
 
-	resp, err := s.client.Do(req)
+	resp, err := s.do(req)
 	if err != nil {
 		return err
 	}
@@ -75,12 +91,13 @@ func (s *SeaweedFSService) Delete(ctx context.Context, p string, extra map[strin
 
 	// Any 2xx response is considered successful.
 	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		s.quotas.invalidate(p)
 		return nil
 	}
 
 	// Read response body to provide more diagnostic information.
 	b, _ := io.ReadAll(resp.Body)
-	return fmt.Errorf("delete failed: %s %s", resp.Status, b)
+	return &StatusError{Op: "delete", Code: resp.StatusCode, Status: resp.Status, Body: string(b)}
 }
 
 // DeleteBatch deletes multiple files concurrently or sequentially depending on concurrency parameter.
@@ -93,12 +110,25 @@ func (s *SeaweedFSService) DeleteBatch(
 	ignoreErrors bool,
 	concurrency int,
 ) map[string]error {
+	return s.deleteBatch(ctx, paths, extra, ignoreErrors, concurrency, s.Delete)
+}
+
+// deleteBatch runs del for every path; internal cleanup passes deleteEntry to bypass the trash.
+// 对每个路径执行 del, 内部清理传入 deleteEntry 以绕过回收站.
+func (s *SeaweedFSService) deleteBatch(
+	ctx context.Context,
+	paths []string,
+	extra map[string]string,
+	ignoreErrors bool,
+	concurrency int,
+	del func(context.Context, string, map[string]string) error,
+) map[string]error {
 
 	// When concurrency <= 1, fall back to sequential execution.
 	if concurrency <= 1 {
 		results := make(map[string]error, len(paths))
 		for _, p := range paths {
-			err := s.Delete(ctx, p, extra)
+			err := del(ctx, p, extra)
 			if ignoreErrors {
 				results[p] = nil
 			} else {
@@ -121,7 +151,7 @@ func (s *SeaweedFSService) DeleteBatch(
 		go func(path string) {
 			defer wg.Done()
 			defer func() { <-sem }()
-			err := s.Delete(ctx, path, extra)
+			err := del(ctx, path, extra)
 			if ignoreErrors {
 				err = nil
 			}
@@ -155,7 +185,7 @@ func (s *SeaweedFSService) Move(ctx context.Context, from, to string) error {
 	q.Set("mv.from", from)
 
 	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, s.FilerEndpoint+to+"?"+q.Encode(), nil)
-	resp, err := s.client.Do(req)
+	resp, err := s.do(req)
 	if err != nil {
 		return err
 	}
@@ -163,8 +193,10 @@ func (s *SeaweedFSService) Move(ctx context.Context, from, to string) error {
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		b, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("move failed: %s %s", resp.Status, b)
+		return &StatusError{Op: "move", Code: resp.StatusCode, Status: resp.Status, Body: string(b)}
 	}
+	s.quotas.invalidate(from)
+	s.quotas.invalidate(to)
 	return nil
 }
 
@@ -184,7 +216,7 @@ func (s *SeaweedFSService) Copy(ctx context.Context, from, to string) error {
 	q.Set("cp.from", from)
 
 	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, s.FilerEndpoint+to+"?"+q.Encode(), nil)
-	resp, err := s.client.Do(req)
+	resp, err := s.do(req)
 	if err != nil {
 		return err
 	}
@@ -192,8 +224,9 @@ func (s *SeaweedFSService) Copy(ctx context.Context, from, to string) error {
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		b, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("copy failed: %s %s", resp.Status, b)
+		return &StatusError{Op: "copy", Code: resp.StatusCode, Status: resp.Status, Body: string(b)}
 	}
+	s.quotas.invalidate(to)
 	return nil
 }
 
@@ -209,6 +242,79 @@ func (s *SeaweedFSService) ListPaged(
 	extra map[string]string,
 ) (ListPagedResult, error) {
 
+	entries, last, err := s.listPage(ctx, dir, lastFileName, limit, namePattern, namePatternExclude, extra)
+	if err != nil {
+		return ListPagedResult{}, err
+	}
+
+	var result []SeaweedEntry
+	for _, e := range entries {
+		result = append(result, SeaweedEntry{
+			// Use base name instead of full path for consumer-friendly output.
+			Name:  path.Base(strings.TrimRight(e.FullPath, "/")),
+			IsDir: e.Mode&uint32(os.ModeDir) != 0,
+			Size:  e.FileSize,
+			Mime:  e.Mime,
+			Mtime: e.Mtime,
+		})
+	}
+
+	return ListPagedResult{
+		Entries: result,
+		Last:    last,
+		// HasMore is inferred from page size and cursor advancement.
+		HasMore: last != "" && len(result) == limit,
+	}, nil
+}
+
+// ListPagedDetail is like ListPaged but returns fully parsed entries, including tags and the chunk
+// count, so no Stat is needed per entry. Tags are taken from the listing; POSIX attributes set
+// through Chmod and friends are applied as in Stat.
+// 与 ListPaged 相同, 但返回完整解析的条目 (包括标签与分片数), 无需对每个条目调用 Stat.
+// 标签取自列表结果, 通过 Chmod 等设置的 POSIX 属性与 Stat 一样会被应用.
+func (s *SeaweedFSService) ListPagedDetail(
+	ctx context.Context,
+	dir string,
+	lastFileName string,
+	limit int,
+	namePattern, namePatternExclude string,
+	extra map[string]string,
+) (ListPagedDetailResult, error) {
+
+	entries, last, err := s.listPage(ctx, dir, lastFileName, limit, namePattern, namePatternExclude, extra)
+	if err != nil {
+		return ListPagedDetailResult{}, err
+	}
+
+	var result []SeaweedEntryDetail
+	for _, e := range entries {
+		d := SeaweedEntryDetail{SeaweedStat: *e.stat(), Chunks: len(e.Chunks), Extended: e.Extended}
+		d.Tags = make(FileTags)
+		for k, v := range e.Extended {
+			if name, ok := strings.CutPrefix(k, "Seaweed-"); ok {
+				d.Tags[name] = string(v)
+			}
+		}
+		result = append(result, d)
+	}
+
+	return ListPagedDetailResult{
+		Entries: result,
+		Last:    last,
+		HasMore: last != "" && len(result) == limit,
+	}, nil
+}
+
+// listPage fetches one page of the raw directory listing. 获取一页原始目录列表.
+func (s *SeaweedFSService) listPage(
+	ctx context.Context,
+	dir string,
+	lastFileName string,
+	limit int,
+	namePattern, namePatternExclude string,
+	extra map[string]string,
+) ([]filerEntry, string, error) {
+
 	// SeaweedFS requires directory paths to end with "/".
 	if !strings.HasSuffix(dir, "/") {
 		dir += "/"
@@ -240,52 +346,32 @@ func (s *SeaweedFSService) ListPaged(
 	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
 	req.Header.Set("Accept", "application/json")
 
-	resp, err := s.client.Do(req)
+	resp, err := s.do(req)
 	if err != nil {
-		return ListPagedResult{}, err
+		return nil, "", err
 	}
 	defer resp.Body.Close()
 
+	// 404 is mapped to os.ErrNotExist for Go-style error handling.
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, "", os.ErrNotExist
+	}
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		b, _ := io.ReadAll(resp.Body)
-		return ListPagedResult{}, fmt.Errorf("list failed: %s %s", resp.Status, b)
+		return nil, "", &StatusError{Op: "list", Code: resp.StatusCode, Status: resp.Status, Body: string(b)}
 	}
 
 	// Decode SeaweedFS directory listing response.
 	var raw struct {
-		Path    string `json:"Path"`
-		Entries []struct {
-			FullPath string `json:"FullPath"`
-			Mtime    string `json:"Mtime"`
-			FileSize int64  `json:"FileSize"`
-			Mime     string `json:"Mime"`
-			Mode     uint32 `json:"Mode"`
-		} `json:"Entries"`
-		LastFileName string `json:"LastFileName"`
+		Path         string       `json:"Path"`
+		Entries      []filerEntry `json:"Entries"`
+		LastFileName string       `json:"LastFileName"`
 	}
 
 	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
-		return ListPagedResult{}, err
+		return nil, "", err
 	}
-
-	var result []SeaweedEntry
-	for _, e := range raw.Entries {
-		result = append(result, SeaweedEntry{
-			// Use base name instead of full path for consumer-friendly output.
-			Name:  path.Base(strings.TrimRight(e.FullPath, "/")),
-			IsDir: e.Mode&uint32(os.ModeDir) != 0,
-			Size:  e.FileSize,
-			Mime:  e.Mime,
-			Mtime: e.Mtime,
-		})
-	}
-
-	return ListPagedResult{
-		Entries: result,
-		Last:    raw.LastFileName,
-		// HasMore is inferred from page size and cursor advancement.
-		HasMore: raw.LastFileName != "" && len(result) == limit,
-	}, nil
+	return raw.Entries, raw.LastFileName, nil
 }
 
 // List lists all entries in a directory, automatically paging through results.
@@ -299,6 +385,42 @@ func (s *SeaweedFSService) List(
 ) ([]SeaweedEntry, error) {
 
 	var all []SeaweedEntry
+	err := s.paginate(ctx, func(last string, limit int) (string, bool, error) {
+		page, err := s.ListPaged(ctx, dir, last, limit, namePattern, namePatternExclude, extra)
+		all = append(all, page.Entries...)
+		return page.Last, page.HasMore, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return all, nil
+}
+
+// ListDetail is like List but returns the fully parsed entries of ListPagedDetail.
+// 与 List 相同, 但返回 ListPagedDetail 的完整解析条目.
+func (s *SeaweedFSService) ListDetail(
+	ctx context.Context,
+	dir string,
+	namePattern, namePatternExclude string,
+	extra map[string]string,
+) ([]SeaweedEntryDetail, error) {
+
+	var all []SeaweedEntryDetail
+	err := s.paginate(ctx, func(last string, limit int) (string, bool, error) {
+		page, err := s.ListPagedDetail(ctx, dir, last, limit, namePattern, namePatternExclude, extra)
+		all = append(all, page.Entries...)
+		return page.Last, page.HasMore, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return all, nil
+}
+
+// paginate calls page with an advancing cursor until it reports no more pages.
+// It respects MaxListPages from the safety policy and supports cancellation via context.
+// 以递进的游标调用 page 直到没有更多分页, 遵守安全策略的 MaxListPages, 并支持 context 取消.
+func (s *SeaweedFSService) paginate(ctx context.Context, page func(last string, limit int) (string, bool, error)) error {
 	last := ""
 	limit := 100
 	pageCount := 0
@@ -307,34 +429,83 @@ func (s *SeaweedFSService) List(
 		// Allow caller to cancel long-running listings.
 		select {
 		case <-ctx.Done():
-			return nil, ctx.Err()
+			return ctx.Err()
 		default:
 		}
 
-		page, err := s.ListPaged(ctx, dir, last, limit, namePattern, namePatternExclude, extra)
+		next, hasMore, err := page(last, limit)
 		if err != nil {
-			return nil, err
+			return err
 		}
-
-		all = append(all, page.Entries...)
-
-		if !page.HasMore {
-			break
+		if !hasMore {
+			return nil
 		}
 
 		// Prevent infinite pagination if the server does not advance cursor.
-		if page.Last == last {
-			return nil, fmt.Errorf("list aborted: lastFileName not advancing (possible infinite pagination)")
+		if next == last {
+			return fmt.Errorf("list aborted: lastFileName not advancing (possible infinite pagination)")
 		}
 
-		last = page.Last
+		last = next
 		pageCount++
 
 		// Enforce safety limit to avoid unbounded listings.
 		if pageCount >= s.policy.MaxListPages {
-			return nil, fmt.Errorf("list aborted: exceed max pages %d", s.policy.MaxListPages)
+			return fmt.Errorf("list aborted: exceed max pages %d", s.policy.MaxListPages)
 		}
 	}
+}
 
-	return all, nil
+// SkipDir can be returned by a WalkFunc to skip the contents of a directory. 由 WalkFunc 返回以跳过目录内容.
+var SkipDir = fs.SkipDir
+
+// WalkFunc is called for every entry visited by Walk with the entry's full path.
+// 由 Walk 对每个访问到的条目调用, p 为条目完整路径.
+type WalkFunc func(p string, e SeaweedEntry) error
+
+// Walk walks the directory tree rooted at dir, calling fn for each entry in lexical order.
+// Returning SkipDir from fn skips the directory's contents (or the rest of the parent directory for a file);
+// any other error stops the walk.
+// 遍历以 dir 为根的目录树, 对每个条目调用 fn. fn 对目录返回 SkipDir 时跳过其内容, 其他错误会终止遍历.
+func (s *SeaweedFSService) Walk(ctx context.Context, dir string, fn WalkFunc) error {
+	err := s.walk(ctx, util.NormalizePath(dir), fn)
+	if errors.Is(err, SkipDir) {
+		return nil
+	}
+	return err
+}
+
+func (s *SeaweedFSService) walk(ctx context.Context, dir string, fn WalkFunc) error {
+	// Respect caller cancellation
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	entries, err := s.List(ctx, dir, "", "", nil)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		p := path.Join(dir, e.Name)
+		err := fn(p, e)
+		if errors.Is(err, SkipDir) {
+			// On a file, SkipDir skips the remaining entries of the parent directory.
+			if e.IsDir {
+				continue
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if e.IsDir {
+			if err := s.walk(ctx, p, fn); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
 }
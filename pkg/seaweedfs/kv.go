@@ -0,0 +1,322 @@
+// Package seaweedfs provides a Go client for interacting with SeaweedFS.
+// It includes a key-value store API built on filer entries.
+// 提供 SeaweedFS 的 Go 客户端, 包括基于 filer 条目的键值存储 API.
+package seaweedfs
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/GoFurry/seaweedfs-sdk-go/internal/util"
+)
+
+// KV is a key-value store on the filer. Keys may contain "/" to group related keys and are
+// namespaced under Root. Every call is retried according to the service's safety policy, and a
+// missing key is reported as os.ErrNotExist.
+//
+// A KV created with NewFilerKV uses the filer's own KV API (KvGet/KvPut). That API has no TTL and
+// no key listing, so Put with a ttl and List fail with errors.ErrUnsupported, and an empty value
+// cannot be told apart from a missing key.
+//
+// A KV created with NewKV works over plain HTTP by storing one filer entry per key, with TTL and
+// List support. Each key costs a filer entry, each call is a full HTTP round trip and List walks
+// the directory tree, so it suits modest numbers of small values.
+//
+// Writes are last-writer-wins on both backends, and batch calls are not atomic.
+// 基于 filer 的键值存储. 键可包含 "/" 用于分组, 并以 Root 为命名空间. 所有调用按服务的安全策略重试,
+// 不存在的键返回 os.ErrNotExist.
+//
+// 通过 NewFilerKV 创建的 KV 使用 filer 自身的 KV API (KvGet/KvPut). 该 API 不支持 TTL 与列出键, 因此带 ttl 的
+// Put 与 List 返回 errors.ErrUnsupported, 且空值与不存在的键无法区分.
+//
+// 通过 NewKV 创建的 KV 仅使用 HTTP, 每个键存储为一个 filer 条目, 支持 TTL 与 List. 每个键占用一个 filer 条目,
+// 每次调用都是一次完整的 HTTP 往返, List 需要遍历目录树, 适用于数量适中的小型值.
+//
+// 两种后端的写入均为后写者胜出, 批量调用也不是原子的.
+type KV struct {
+	s      *SeaweedFSService
+	native FilerKVClient
+	Root   string // Root directory (key prefix with NewFilerKV) of the store / 存储根目录 (NewFilerKV 下为键前缀)
+}
+
+// FilerKVClient is the filer's native key-value API, i.e. the KvGet and KvPut calls of the filer
+// gRPC service. The SDK only speaks HTTP, so callers adapt their gRPC client: KvGet returns an
+// empty value for a missing key, and KvPut with an empty value deletes the key.
+// filer 原生键值 API, 即 filer gRPC 服务的 KvGet 与 KvPut 调用. SDK 仅使用 HTTP, 因此由调用方适配其 gRPC
+// 客户端: 键不存在时 KvGet 返回空值, KvPut 写入空值时删除该键.
+type FilerKVClient interface {
+	KvGet(ctx context.Context, key []byte) ([]byte, error)
+	KvPut(ctx context.Context, key, value []byte) error
+}
+
+// NewKV creates a key-value store rooted at root that stores one filer entry per key.
+// 创建以 root 为根、每个键存储为一个 filer 条目的键值存储.
+func NewKV(s *SeaweedFSService, root string) *KV {
+	return &KV{s: s, Root: util.NormalizePath(root)}
+}
+
+// NewFilerKV creates a key-value store on the filer's native KV API, with keys prefixed by root.
+// 创建基于 filer 原生 KV API 的键值存储, 键以 root 为前缀.
+func NewFilerKV(s *SeaweedFSService, client FilerKVClient, root string) *KV {
+	return &KV{s: s, native: client, Root: util.NormalizePath(root)}
+}
+
+// keyPath validates key and returns its filer path. 校验键并返回其 filer 路径.
+func (kv *KV) keyPath(key string) (string, error) {
+	if key == "" || strings.HasPrefix(key, "/") || strings.HasSuffix(key, "/") {
+		return "", fmt.Errorf("invalid kv key %q", key)
+	}
+	for _, seg := range strings.Split(key, "/") {
+		if seg == "" || seg == "." || seg == ".." {
+			return "", fmt.Errorf("invalid kv key %q", key)
+		}
+	}
+	return JoinPath(kv.Root, key), nil
+}
+
+// Get returns the value stored under key, or os.ErrNotExist. 返回键对应的值, 不存在时返回 os.ErrNotExist.
+func (kv *KV) Get(ctx context.Context, key string) ([]byte, error) {
+	p, err := kv.keyPath(key)
+	if err != nil {
+		return nil, err
+	}
+
+	var value []byte
+	if kv.native != nil {
+		err = kv.s.retry(ctx, func() error {
+			value, err = kv.native.KvGet(ctx, []byte(p))
+			return err
+		})
+		if err == nil && len(value) == 0 {
+			return nil, os.ErrNotExist
+		}
+		return value, err
+	}
+
+	err = kv.s.retry(ctx, func() error {
+		rc, _, err := kv.s.Download(ctx, p, nil)
+		if err != nil {
+			return err
+		}
+		defer rc.Close()
+		value, err = io.ReadAll(rc)
+		return err
+	})
+	return value, err
+}
+
+// Put stores value under key. A positive ttl lets the filer expire the entry
+// (minute granularity, see FormatTTL); the native KV API does not support it.
+// 将 value 存储到 key 下, ttl 为正时由 filer 自动过期该条目 (分钟粒度, 见 FormatTTL), 原生 KV API 不支持 ttl.
+func (kv *KV) Put(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	p, err := kv.keyPath(key)
+	if err != nil {
+		return err
+	}
+
+	if kv.native != nil {
+		if ttl > 0 {
+			return fmt.Errorf("kv put %s: ttl on the filer KV API: %w", key, errors.ErrUnsupported)
+		}
+		if len(value) == 0 {
+			return fmt.Errorf("kv put %s: the filer KV API cannot store an empty value", key)
+		}
+		return kv.s.retry(ctx, func() error {
+			return kv.native.KvPut(ctx, []byte(p), value)
+		})
+	}
+
+	var opts map[string]string
+	if ttl > 0 {
+		opts = map[string]string{"ttl": FormatTTL(ttl)}
+	}
+	headers := map[string]string{"Content-Type": "application/octet-stream"}
+
+	return kv.s.retry(ctx, func() error {
+		return kv.s.UploadWithOptions(ctx, UploadMethodPut, p, bytes.NewReader(value), opts, headers, nil)
+	})
+}
+
+// Delete removes key. Deleting a missing key is not an error. 删除键, 删除不存在的键不视为错误.
+func (kv *KV) Delete(ctx context.Context, key string) error {
+	p, err := kv.keyPath(key)
+	if err != nil {
+		return err
+	}
+
+	if kv.native != nil {
+		return kv.s.retry(ctx, func() error {
+			return kv.native.KvPut(ctx, []byte(p), nil)
+		})
+	}
+
+	err = kv.s.retry(ctx, func() error {
+		return kv.s.deleteEntry(ctx, p, nil)
+	})
+	var se *StatusError
+	if errors.As(err, &se) && se.Code == http.StatusNotFound {
+		return nil
+	}
+	return err
+}
+
+// List returns all keys starting with prefix in lexical order. An empty prefix lists every key.
+// The native KV API cannot list keys.
+// 按字典序返回所有以 prefix 开头的键, prefix 为空时列出全部键. 原生 KV API 不支持列出键.
+func (kv *KV) List(ctx context.Context, prefix string) ([]string, error) {
+	if kv.native != nil {
+		return nil, fmt.Errorf("kv list: %w", errors.ErrUnsupported)
+	}
+
+	// Only walk the deepest directory the prefix fully names.
+	dir := kv.Root
+	if i := strings.LastIndex(prefix, "/"); i >= 0 {
+		dir = JoinPath(kv.Root, prefix[:i])
+	}
+
+	var keys []string
+	err := kv.s.retry(ctx, func() error {
+		keys = keys[:0]
+		return kv.s.Walk(ctx, dir, func(p string, e SeaweedEntry) error {
+			key := strings.TrimPrefix(p, strings.TrimSuffix(kv.Root, "/")+"/")
+			if e.IsDir {
+				// Skip subtrees that cannot contain matching keys.
+				if !strings.HasPrefix(key+"/", prefix) && !strings.HasPrefix(prefix, key+"/") {
+					return SkipDir
+				}
+				return nil
+			}
+			if strings.HasPrefix(key, prefix) {
+				keys = append(keys, key)
+			}
+			return nil
+		})
+	})
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// GetBatch returns the values of keys fetched concurrently. Missing keys are omitted from the result;
+// any other error aborts the batch.
+// 并发获取多个键的值, 不存在的键不出现在结果中, 其他错误会终止整个批次.
+func (kv *KV) GetBatch(ctx context.Context, keys []string, concurrency int) (map[string][]byte, error) {
+	result := make(map[string][]byte, len(keys))
+	mu := sync.Mutex{}
+
+	err := kv.batch(ctx, keys, concurrency, func(ctx context.Context, key string) error {
+		value, err := kv.Get(ctx, key)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("get %s: %w", key, err)
+		}
+		mu.Lock()
+		result[key] = value
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// PutBatch stores all items concurrently with the same ttl. 使用相同 ttl 并发存储所有键值.
+func (kv *KV) PutBatch(ctx context.Context, items map[string][]byte, ttl time.Duration, concurrency int) error {
+	keys := make([]string, 0, len(items))
+	for k := range items {
+		keys = append(keys, k)
+	}
+
+	return kv.batch(ctx, keys, concurrency, func(ctx context.Context, key string) error {
+		if err := kv.Put(ctx, key, items[key], ttl); err != nil {
+			return fmt.Errorf("put %s: %w", key, err)
+		}
+		return nil
+	})
+}
+
+// DeleteBatch removes keys concurrently. 并发删除多个键.
+func (kv *KV) DeleteBatch(ctx context.Context, keys []string, concurrency int) error {
+	return kv.batch(ctx, keys, concurrency, func(ctx context.Context, key string) error {
+		if err := kv.Delete(ctx, key); err != nil {
+			return fmt.Errorf("delete %s: %w", key, err)
+		}
+		return nil
+	})
+}
+
+// batch runs fn for every key with at most concurrency calls in flight, stopping at the first error.
+// 以不超过 concurrency 的并发对每个键执行 fn, 遇到第一个错误时停止.
+func (kv *KV) batch(ctx context.Context, keys []string, concurrency int, fn func(context.Context, string) error) error {
+	// Apply a sane default when concurrency is not specified.
+	if concurrency <= 0 {
+		concurrency = 10
+	}
+
+	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(concurrency)
+	for _, key := range keys {
+		g.Go(func() error {
+			return fn(ctx, key)
+		})
+	}
+	return g.Wait()
+}
+
+// GetJSON decodes the JSON value stored under key into v. 将键对应的 JSON 值解码到 v.
+func (kv *KV) GetJSON(ctx context.Context, key string, v any) error {
+	b, err := kv.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+// PutJSON stores v encoded as JSON under key. 将 v 编码为 JSON 存储到 key 下.
+func (kv *KV) PutJSON(ctx context.Context, key string, v any, ttl time.Duration) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return kv.Put(ctx, key, b, ttl)
+}
+
+// GetGob decodes the gob value stored under key into v. 将键对应的 gob 值解码到 v.
+func (kv *KV) GetGob(ctx context.Context, key string, v any) error {
+	b, err := kv.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+	return gob.NewDecoder(bytes.NewReader(b)).Decode(v)
+}
+
+// PutGob stores v encoded with encoding/gob under key. 将 v 以 gob 编码存储到 key 下.
+func (kv *KV) PutGob(ctx context.Context, key string, v any, ttl time.Duration) error {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return err
+	}
+	return kv.Put(ctx, key, buf.Bytes(), ttl)
+}
@@ -0,0 +1,121 @@
+package seaweedfs
+
+import (
+	"context"
+	"errors"
+	"os"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memKV is a FilerKVClient with the filer's semantics: missing keys read as empty and empty
+// values delete.
+type memKV struct {
+	mu sync.Mutex
+	m  map[string][]byte
+}
+
+func (c *memKV) KvGet(_ context.Context, key []byte) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.m[string(key)], nil
+}
+
+func (c *memKV) KvPut(_ context.Context, key, value []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(value) == 0 {
+		delete(c.m, string(key))
+		return nil
+	}
+	c.m[string(key)] = append([]byte(nil), value...)
+	return nil
+}
+
+// testKV runs the checks shared by both backends.
+func testKV(t *testing.T, kv *KV) {
+	t.Helper()
+	ctx := context.Background()
+
+	if _, err := kv.Get(ctx, "missing"); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("missing key: got %v, want os.ErrNotExist", err)
+	}
+	for _, key := range []string{"", "/a", "a/", "a//b", "../a", "a/./b"} {
+		if err := kv.Put(ctx, key, []byte("v"), 0); err == nil {
+			t.Fatalf("key %q accepted", key)
+		}
+	}
+
+	items := map[string][]byte{"users/1": []byte("ann"), "users/2": []byte("bob"), "config": []byte("{}")}
+	if err := kv.PutBatch(ctx, items, 0, 2); err != nil {
+		t.Fatal(err)
+	}
+	got, err := kv.GetBatch(ctx, []string{"users/1", "users/2", "config", "missing"}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, items) {
+		t.Fatalf("batch: got %q, want %q", got, items)
+	}
+
+	type cfg struct{ Name string }
+	if err := kv.PutJSON(ctx, "json", cfg{"a"}, 0); err != nil {
+		t.Fatal(err)
+	}
+	var c cfg
+	if err := kv.GetJSON(ctx, "json", &c); err != nil || c.Name != "a" {
+		t.Fatalf("json: got %+v, %v", c, err)
+	}
+	if err := kv.PutGob(ctx, "gob", cfg{"b"}, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := kv.GetGob(ctx, "gob", &c); err != nil || c.Name != "b" {
+		t.Fatalf("gob: got %+v, %v", c, err)
+	}
+
+	if err := kv.DeleteBatch(ctx, []string{"users/1", "missing"}, 2); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := kv.Get(ctx, "users/1"); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("deleted key: got %v, want os.ErrNotExist", err)
+	}
+}
+
+func TestKVFiles(t *testing.T) {
+	f, srv := newFakeFiler(t)
+	kv := NewKV(NewSeaweedFSService(srv.URL), "/kv")
+	testKV(t, kv)
+
+	if got, ok := f.file("/kv/users/2"); !ok || string(got) != "bob" {
+		t.Fatalf("stored entry: got %q, %v", got, ok)
+	}
+	keys, err := kv.List(context.Background(), "users/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(keys, []string{"users/2"}) {
+		t.Fatalf("list: got %q", keys)
+	}
+}
+
+func TestKVNative(t *testing.T) {
+	_, srv := newFakeFiler(t)
+	client := &memKV{m: make(map[string][]byte)}
+	kv := NewFilerKV(NewSeaweedFSService(srv.URL), client, "/kv")
+	testKV(t, kv)
+
+	// Keys are namespaced by the root.
+	if string(client.m["/kv/users/2"]) != "bob" {
+		t.Fatalf("native keys: %q", client.m)
+	}
+
+	ctx := context.Background()
+	if err := kv.Put(ctx, "ttl", []byte("v"), time.Hour); !errors.Is(err, errors.ErrUnsupported) {
+		t.Fatalf("ttl: got %v, want errors.ErrUnsupported", err)
+	}
+	if _, err := kv.List(ctx, ""); !errors.Is(err, errors.ErrUnsupported) {
+		t.Fatalf("list: got %v, want errors.ErrUnsupported", err)
+	}
+}
@@ -0,0 +1,353 @@
+// Package seaweedfs provides a Go client for interacting with SeaweedFS.
+// It includes a declarative lifecycle rules engine for expiring, moving, transitioning and tagging data.
+// 提供 SeaweedFS 的 Go 客户端, 包括用于过期删除、移动、迁移与打标签的声明式生命周期规则引擎.
+package seaweedfs
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/GoFurry/seaweedfs-sdk-go/internal/util"
+)
+
+// LifecycleAction is what a lifecycle rule does with a matching entry. 生命周期规则对匹配条目执行的动作.
+type LifecycleAction int
+
+const (
+	LifecycleDelete     LifecycleAction = iota + 1 // Delete the entry (honours trash mode) / 删除条目 (遵循回收站模式)
+	LifecycleMove                                  // Move under Target, keeping the path below the prefix / 移动到 Target 下, 保留前缀以下的路径
+	LifecycleTransition                            // Rewrite into Collection and/or with TTL / 以新的 Collection 和/或 TTL 重写
+	LifecycleTag                                   // Apply SetTags / 设置 SetTags 标签
+)
+
+func (a LifecycleAction) String() string {
+	switch a {
+	case LifecycleDelete:
+		return "delete"
+	case LifecycleMove:
+		return "move"
+	case LifecycleTransition:
+		return "transition"
+	case LifecycleTag:
+		return "tag"
+	default:
+		return fmt.Sprintf("LifecycleAction(%d)", int(a))
+	}
+}
+
+// LifecycleRule matches files by path prefix, tags, MIME type and age and applies one action to them.
+// All set conditions must hold; empty conditions match everything.
+// 按路径前缀、标签、MIME 类型与时长匹配文件并执行一个动作, 所有已设置的条件都需满足, 未设置的条件匹配一切.
+type LifecycleRule struct {
+	Name   string            // Rule name used in reports / 报告中使用的规则名称
+	Prefix string            // Path prefix, e.g. "/logs/" or "/logs/app-" / 路径前缀
+	Tags   map[string]string // Required tag values / 需匹配的标签值
+	Mime   string            // Required MIME type or prefix such as "image/" / 需匹配的 MIME 类型或前缀
+	MinAge time.Duration     // Minimum age since last modification / 距最后修改的最短时长
+
+	Action     LifecycleAction // Action to apply / 执行的动作
+	Target     string          // Destination directory for LifecycleMove / LifecycleMove 的目标目录
+	Collection string          // Destination collection for LifecycleTransition / LifecycleTransition 的目标集合
+	TTL        time.Duration   // TTL for LifecycleTransition / LifecycleTransition 设置的 TTL
+	SetTags    FileTags        // Tags for LifecycleTag / LifecycleTag 设置的标签
+}
+
+// Validate checks that the rule is complete for its action. 检查规则对其动作是否完整.
+func (r LifecycleRule) Validate() error {
+	if !strings.HasPrefix(r.Prefix, "/") {
+		return fmt.Errorf("lifecycle rule %q: prefix must be an absolute path", r.Name)
+	}
+	switch r.Action {
+	case LifecycleDelete:
+	case LifecycleMove:
+		if r.Target == "" {
+			return fmt.Errorf("lifecycle rule %q: move requires a target", r.Name)
+		}
+	case LifecycleTransition:
+		if r.Collection == "" && r.TTL <= 0 {
+			return fmt.Errorf("lifecycle rule %q: transition requires a collection or ttl", r.Name)
+		}
+	case LifecycleTag:
+		if len(r.SetTags) == 0 {
+			return fmt.Errorf("lifecycle rule %q: tag requires tags", r.Name)
+		}
+	default:
+		return fmt.Errorf("lifecycle rule %q: unknown action %v", r.Name, r.Action)
+	}
+	return nil
+}
+
+// base returns the directory part of the prefix, which LifecycleMove strips from moved paths.
+// 返回前缀的目录部分, LifecycleMove 会从被移动路径中去掉该部分.
+func (r LifecycleRule) base() string {
+	if strings.HasSuffix(r.Prefix, "/") {
+		return r.Prefix
+	}
+	return path.Dir(r.Prefix) + "/"
+}
+
+// destination returns where LifecycleMove puts p. 返回 LifecycleMove 移动 p 的目标路径.
+func (r LifecycleRule) destination(p string) string {
+	return JoinPath(r.Target, strings.TrimPrefix(p, r.base()))
+}
+
+// LifecycleResult records one action taken (or planned, in dry-run) by the runner.
+// 记录执行器执行 (或在演练模式下计划执行) 的一个动作.
+type LifecycleResult struct {
+	Path   string          // Entry path / 条目路径
+	Rule   string          // Matching rule name / 匹配的规则名称
+	Action LifecycleAction // Action / 动作
+	Target string          // Destination path or collection, if any / 目标路径或集合
+	Err    error           // Error, nil on success / 错误, 成功时为 nil
+}
+
+// LifecycleReport summarises a lifecycle run. 生命周期执行结果汇总.
+type LifecycleReport struct {
+	DryRun  bool              // Whether actions were only planned / 是否仅为演练
+	Scanned int               // Files scanned / 扫描的文件数
+	Matched int               // Files matching a rule / 匹配规则的文件数
+	Failed  int               // Actions that failed / 失败的动作数
+	Results []LifecycleResult // Actions in the order they were applied / 按执行顺序排列的动作
+}
+
+// LifecycleRunner walks a tree and applies the first matching rule to every file.
+// Entries under a move target, the trash and lock directories, Exclude, .versions directories and
+// atomic-upload temp files are skipped.
+// 遍历目录树并对每个文件应用第一个匹配的规则, 会跳过移动目标目录、回收站与锁目录、Exclude、.versions 目录及原子上传临时文件.
+type LifecycleRunner struct {
+	s       *SeaweedFSService
+	Rules   []LifecycleRule // Rules in priority order / 按优先级排列的规则
+	Rate    float64         // Maximum actions per second, 0 for unlimited / 每秒最大动作数, 0 表示不限制
+	DryRun  bool            // Report planned actions without applying them / 只报告计划执行的动作而不实际执行
+	Exclude []string        // Further directories to skip, e.g. CASStore roots / 额外跳过的目录, 如 CASStore 根目录
+}
+
+// NewLifecycleRunner creates a runner for the given rules. 使用给定规则创建执行器.
+func NewLifecycleRunner(s *SeaweedFSService, rules ...LifecycleRule) *LifecycleRunner {
+	return &LifecycleRunner{s: s, Rules: rules}
+}
+
+// Run walks root and applies the rules. Individual action errors are recorded in the report;
+// walk errors and context cancellation stop the run.
+// 遍历 root 并应用规则. 单个动作的错误记录在报告中, 遍历错误与 context 取消会终止执行.
+func (lr *LifecycleRunner) Run(ctx context.Context, root string) (LifecycleReport, error) {
+	report := LifecycleReport{DryRun: lr.DryRun}
+	for _, r := range lr.Rules {
+		if err := r.Validate(); err != nil {
+			return report, err
+		}
+	}
+
+	var interval time.Duration
+	if lr.Rate > 0 {
+		interval = time.Duration(float64(time.Second) / lr.Rate)
+	}
+	var next time.Time
+
+	err := lr.s.Walk(ctx, root, func(p string, e SeaweedEntry) error {
+		if e.IsDir {
+			if e.Name == VersionsDirName || lr.isTarget(p) || lr.isInternal(p) {
+				return SkipDir
+			}
+			return nil
+		}
+		if strings.HasPrefix(e.Name, AtomicTempPrefix) {
+			return nil
+		}
+		report.Scanned++
+
+		rule, ok, err := lr.match(ctx, p, e)
+		if err != nil {
+			report.Results = append(report.Results, LifecycleResult{Path: p, Err: err})
+			report.Failed++
+			return nil
+		}
+		if !ok {
+			return nil
+		}
+		report.Matched++
+
+		res := LifecycleResult{Path: p, Rule: rule.Name, Action: rule.Action}
+		switch rule.Action {
+		case LifecycleMove:
+			res.Target = rule.destination(p)
+		case LifecycleTransition:
+			res.Target = rule.Collection
+		}
+
+		if !lr.DryRun {
+			if interval > 0 {
+				if wait := time.Until(next); wait > 0 {
+					select {
+					case <-ctx.Done():
+						return ctx.Err()
+					case <-time.After(wait):
+					}
+				}
+				next = time.Now().Add(interval)
+			}
+			res.Err = lr.apply(ctx, rule, p)
+			if res.Err != nil {
+				report.Failed++
+			}
+		}
+		report.Results = append(report.Results, res)
+		return nil
+	})
+	return report, err
+}
+
+// isTarget reports whether dir is the target of a move rule. 判断 dir 是否为某个移动规则的目标目录.
+func (lr *LifecycleRunner) isTarget(dir string) bool {
+	for _, r := range lr.Rules {
+		if r.Action == LifecycleMove && util.NormalizePath(r.Target) == dir {
+			return true
+		}
+	}
+	return false
+}
+
+// isInternal reports whether dir holds SDK or caller bookkeeping that rules must not touch: trashed
+// entries are only purged by PurgeTrash, and lock files carry the fencing counters.
+// 判断 dir 是否存放规则不应触碰的 SDK 或调用方内部数据: 回收站条目只由 PurgeTrash 清理, 锁文件保存着 fencing 计数器.
+func (lr *LifecycleRunner) isInternal(dir string) bool {
+	if dir == lr.s.trashDir || dir == lr.s.lockDir {
+		return true
+	}
+	for _, ex := range lr.Exclude {
+		if util.NormalizePath(ex) == dir {
+			return true
+		}
+	}
+	return false
+}
+
+// match returns the first rule matching p. Tags are only fetched when a candidate rule needs them,
+// or when the entry looks too young for a rule: a rewritten entry keeps its original modification
+// time in the Mtime tag.
+// 返回第一个匹配 p 的规则, 仅在候选规则需要或条目看起来不满足最短时长时才获取标签: 重写过的条目在 Mtime
+// 标签中保留原修改时间.
+func (lr *LifecycleRunner) match(ctx context.Context, p string, e SeaweedEntry) (LifecycleRule, bool, error) {
+	age := time.Since(util.ParseSeaweedTime(e.Mtime))
+	var tags FileTags
+	loadTags := func() error {
+		if tags != nil {
+			return nil
+		}
+		var err error
+		if tags, err = lr.s.GetTags(ctx, p); err != nil {
+			return err
+		}
+		if t, err := time.Parse(time.RFC3339Nano, tags[mtimeTag]); err == nil {
+			age = time.Since(t)
+		}
+		return nil
+	}
+
+	for _, r := range lr.Rules {
+		if !strings.HasPrefix(p, r.Prefix) {
+			continue
+		}
+		if r.Mime != "" && !strings.HasPrefix(e.Mime, r.Mime) {
+			continue
+		}
+		if r.MinAge > 0 && age < r.MinAge {
+			if err := loadTags(); err != nil {
+				return LifecycleRule{}, false, err
+			}
+			if age < r.MinAge {
+				continue
+			}
+		}
+		if len(r.Tags) > 0 {
+			if err := loadTags(); err != nil {
+				return LifecycleRule{}, false, err
+			}
+			if !tagsMatch(tags, r.Tags) || age < r.MinAge {
+				continue
+			}
+		}
+		return r, true, nil
+	}
+	return LifecycleRule{}, false, nil
+}
+
+// tagsMatch reports whether tags contains every key/value in want. 判断 tags 是否包含 want 中的所有键值.
+func tagsMatch(tags FileTags, want map[string]string) bool {
+	for k, v := range want {
+		got, ok := tags[k]
+		if !ok {
+			got, ok = tags[http.CanonicalHeaderKey(k)]
+		}
+		if !ok || got != v {
+			return false
+		}
+	}
+	return true
+}
+
+// apply performs rule's action on p. 对 p 执行规则动作.
+func (lr *LifecycleRunner) apply(ctx context.Context, rule LifecycleRule, p string) error {
+	switch rule.Action {
+	case LifecycleDelete:
+		return lr.s.Delete(ctx, p, nil)
+	case LifecycleMove:
+		dst := rule.destination(p)
+		if err := lr.s.Mkdir(ctx, path.Dir(dst)); err != nil {
+			return err
+		}
+		return lr.s.Move(ctx, p, dst)
+	case LifecycleTransition:
+		return lr.s.rewrite(ctx, p, rule.Collection, rule.TTL)
+	case LifecycleTag:
+		return lr.s.SetTags(ctx, p, rule.SetTags)
+	}
+	return fmt.Errorf("unknown lifecycle action %v", rule.Action)
+}
+
+// rewrite re-uploads p's stored bytes into collection and/or with ttl, keeping its content type and tags.
+// The filer cannot change either on an existing entry, so the data is streamed back in place; the
+// original modification time is kept in the Mtime tag. Entries already in place are left alone.
+// 将 p 的存储内容以新的集合和/或 TTL 重新上传, 保留 Content-Type 与标签.
+// filer 无法修改已有条目的这两项属性, 因此数据会被流式地原地重写, 原修改时间保存在 Mtime 标签中. 已满足要求的条目不做处理.
+func (s *SeaweedFSService) rewrite(ctx context.Context, p, collection string, ttl time.Duration) error {
+	stat, err := s.Stat(ctx, p, true)
+	if err != nil {
+		return err
+	}
+	want, _ := ParseTTL(FormatTTL(ttl))
+	if (collection == "" || stat.Collection == collection) &&
+		(ttl <= 0 || time.Duration(stat.TtlSec)*time.Second == want) {
+		return nil
+	}
+
+	rc, header, _, err := s.DownloadWithOptions(ctx, p, nil, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	opts := make(map[string]string)
+	if collection != "" {
+		opts["collection"] = collection
+	}
+	if ttl > 0 {
+		opts["ttl"] = FormatTTL(ttl)
+	}
+
+	headers := make(map[string]string)
+	for k, v := range header {
+		if strings.HasPrefix(k, "Seaweed-") && len(v) > 0 {
+			headers[k] = v[0]
+		}
+	}
+	if ct := header.Get("Content-Type"); ct != "" {
+		headers["Content-Type"] = ct
+	}
+	headers["Seaweed-"+mtimeTag] = modTime(stat).UTC().Format(time.RFC3339Nano)
+	return s.UploadWithOptions(ctx, UploadMethodPut, p, rc, opts, headers, nil)
+}
@@ -0,0 +1,101 @@
+package seaweedfs
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLifecycleSkipsInternalDirs(t *testing.T) {
+	f, srv := newFakeFiler(t)
+	s := NewSeaweedFSService(srv.URL, WithTrash(""))
+	ctx := context.Background()
+
+	if err := putBytes(s, "/data/old", 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := putBytes(s, "/data/trashed", 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Delete(ctx, "/data/trashed", nil); err != nil {
+		t.Fatal(err)
+	}
+	l, err := s.TryLock(ctx, "job", time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Unlock(ctx)
+	c := NewCASStore(s, "/cas")
+	if _, err := c.Put(ctx, "a", strings.NewReader("blob"), 4, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	lr := NewLifecycleRunner(s, LifecycleRule{Name: "all", Prefix: "/", Action: LifecycleDelete})
+	lr.Exclude = []string{"/cas"}
+	report, err := lr.Run(ctx, "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Matched != 1 || report.Results[0].Path != "/data/old" {
+		t.Fatalf("report: %+v", report)
+	}
+
+	entries, err := s.ListTrash(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	origins := map[string]bool{}
+	for _, e := range entries {
+		origins[e.OriginalPath] = true
+	}
+	if len(entries) != 2 || !origins["/data/trashed"] || !origins["/data/old"] {
+		t.Fatalf("trash: %+v", entries)
+	}
+	if !exists(f, "/.locks/job") || !exists(f, "/.locks/.tokens/job") || !exists(f, "/cas/names/a") {
+		t.Fatal("internal entries touched")
+	}
+}
+
+func TestLifecycleRules(t *testing.T) {
+	f, srv := newFakeFiler(t)
+	s := NewSeaweedFSService(srv.URL)
+	ctx := context.Background()
+
+	for _, p := range []string{"/logs/app-1", "/logs/db-1", "/tmp/x", "/img/y"} {
+		if err := putBytes(s, p, 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	f.backdate("/logs/app-1", 48*time.Hour)
+	f.backdate("/tmp/x", 48*time.Hour)
+	if err := s.SetTags(ctx, "/img/y", FileTags{"Class": "cold"}); err != nil {
+		t.Fatal(err)
+	}
+
+	lr := NewLifecycleRunner(s,
+		LifecycleRule{Name: "archive", Prefix: "/logs/app-", MinAge: 24 * time.Hour, Action: LifecycleMove, Target: "/archive"},
+		LifecycleRule{Name: "expire", Prefix: "/tmp/", MinAge: 24 * time.Hour, Action: LifecycleDelete},
+		LifecycleRule{Name: "mark", Prefix: "/img/", Tags: map[string]string{"Class": "cold"}, Action: LifecycleTag, SetTags: FileTags{"Seen": "1"}},
+	)
+
+	lr.DryRun = true
+	report, err := lr.Run(ctx, "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Scanned != 4 || report.Matched != 3 || !exists(f, "/tmp/x") {
+		t.Fatalf("dry run: %+v", report)
+	}
+
+	lr.DryRun = false
+	if report, err = lr.Run(ctx, "/"); err != nil || report.Failed != 0 {
+		t.Fatalf("run: %+v, %v", report, err)
+	}
+	if !exists(f, "/archive/app-1") || exists(f, "/logs/app-1") || exists(f, "/tmp/x") || !exists(f, "/logs/db-1") {
+		t.Fatal("move or delete not applied")
+	}
+	if tags, err := s.GetTags(ctx, "/img/y"); err != nil || tags["Seen"] != "1" {
+		t.Fatalf("tags: %v, %v", tags, err)
+	}
+}
@@ -0,0 +1,324 @@
+// Package seaweedfs provides a Go client for interacting with SeaweedFS.
+// It includes a lease-based distributed lock backed by the filer.
+// 提供 SeaweedFS 的 Go 客户端, 包括基于 filer 的租约式分布式锁.
+package seaweedfs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Lock state is kept in tags on the lock file. 锁状态保存在锁文件的标签中.
+const (
+	lockTagHolder  = "Lock-Holder"
+	lockTagExpires = "Lock-Expires"
+	lockTagToken   = "Lock-Token"
+
+	defaultLockDir = "/.locks"
+	lockTokenDir   = ".tokens"
+)
+
+// MinLockTTL is the shortest lease TryLock and Lock accept. TryLock 与 Lock 接受的最短租约.
+const MinLockTTL = 100 * time.Millisecond
+
+var (
+	// ErrLockHeld is returned when the lock is held by another holder whose lease has not expired.
+	// 锁被其他持有者持有且租约未过期时返回.
+	ErrLockHeld = errors.New("lock is held by another holder")
+	// ErrLockLost is returned when a lease was taken over or could not be renewed in time.
+	// 租约被接管或未能及时续约时返回.
+	ErrLockLost = errors.New("lock lease lost")
+)
+
+// WithLockDir sets the filer directory that holds lock files (default "/.locks"). 设置锁文件所在目录.
+func WithLockDir(dir string) Option {
+	return func(s *SeaweedFSService) {
+		if dir != "" {
+			s.lockDir = NormalizePath(dir)
+		}
+	}
+}
+
+// WithLockHolder sets the identity recorded as lock holder (default hostname-pid-random).
+// 设置记录为锁持有者的身份 (默认 hostname-pid-随机数).
+func WithLockHolder(id string) Option {
+	return func(s *SeaweedFSService) {
+		if id != "" {
+			s.lockHolder = id
+		}
+	}
+}
+
+func defaultLockHolder() string {
+	host, _ := os.Hostname()
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+	return fmt.Sprintf("%s-%d-%s", host, os.Getpid(), hex.EncodeToString(b))
+}
+
+// Lease is a held lock. It is renewed in the background until Unlock is called or the lease is lost.
+// Token is a fencing token taken from a per-lock counter under the lock directory that survives
+// Unlock and increases with every acquisition; pass it to downstream systems so they can reject
+// writes from stale holders.
+//
+// The filer has no compare-and-swap, so the lock is advisory: an acquisition writes the lock file,
+// waits a short settle delay and reads it back, which leaves a single holder unless a competing
+// write is delayed by more than the settle delay. Renewal re-checks the holder every ttl/3, so such
+// a late loser sees Lost within a third of the lease; rely on the fencing token for correctness.
+// 表示已持有的锁, 在调用 Unlock 或租约丢失前后台自动续约. Token 为 fencing token, 取自锁目录下每个锁的计数器,
+// 该计数器在 Unlock 后保留并随每次获取递增, 可传递给下游系统以拒绝过期持有者的写入.
+//
+// filer 不支持比较并交换, 因此该锁是建议性的: 获取时写入锁文件, 等待短暂的稳定延迟后读回校验, 除非竞争者的写入
+// 延迟超过稳定延迟, 否则只会有一个持有者. 续约每隔 ttl/3 重新检查持有者, 这种迟到的失败方会在三分之一租约内
+// 收到 Lost; 正确性应依赖 fencing token.
+type Lease struct {
+	Name   string // Lock name / 锁名称
+	Path   string // Lock file path / 锁文件路径
+	Holder string // Holder identity / 持有者身份
+	Token  int64  // Fencing token / fencing token
+
+	s    *SeaweedFSService
+	ttl  time.Duration
+	mu   sync.Mutex
+	exp  time.Time
+	err  error
+	once sync.Once
+	stop chan struct{}
+	done chan struct{}
+	lost chan struct{}
+}
+
+// Lock acquires the named lock with a lease of ttl, waiting until it is available or ctx is done.
+// 获取指定名称的锁, 租约时长为 ttl, 一直等待直到可用或 ctx 结束.
+func (s *SeaweedFSService) Lock(ctx context.Context, name string, ttl time.Duration) (*Lease, error) {
+	wait := ttl / 4
+	if wait > time.Second {
+		wait = time.Second
+	}
+	if wait < 50*time.Millisecond {
+		wait = 50 * time.Millisecond
+	}
+
+	for {
+		l, err := s.TryLock(ctx, name, ttl)
+		if !errors.Is(err, ErrLockHeld) {
+			return l, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+// TryLock makes a single attempt to acquire the named lock and returns ErrLockHeld if it is taken.
+// An expired lease is taken over.
+// 尝试获取一次指定名称的锁, 已被持有时返回 ErrLockHeld, 过期租约会被接管.
+func (s *SeaweedFSService) TryLock(ctx context.Context, name string, ttl time.Duration) (*Lease, error) {
+	if ttl < MinLockTTL {
+		return nil, fmt.Errorf("lock ttl %s is shorter than %s", ttl, MinLockTTL)
+	}
+
+	p := JoinPath(s.lockDir, name)
+	pre := Precondition{CreateOnly: true}
+	var prev int64
+
+	stat, err := s.Stat(ctx, p, true)
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return nil, err
+	default:
+		exp, _ := time.Parse(time.RFC3339Nano, stat.Tags[lockTagExpires])
+		if time.Now().Before(exp) {
+			return nil, fmt.Errorf("%w: %s by %s until %s", ErrLockHeld, name, stat.Tags[lockTagHolder], exp.Format(time.RFC3339))
+		}
+		// Take over the expired lease, guarding against a concurrent takeover.
+		pre = Precondition{IfMtimeUnchanged: stat.Mtime}
+		prev, _ = strconv.ParseInt(stat.Tags[lockTagToken], 10, 64)
+	}
+
+	token, err := s.nextLockToken(ctx, name, prev)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &Lease{
+		Name:   name,
+		Path:   p,
+		Holder: s.lockHolder,
+		Token:  token,
+		s:      s,
+		ttl:    ttl,
+		exp:    time.Now().Add(ttl),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+		lost:   make(chan struct{}),
+	}
+
+	err = s.UploadIf(ctx, pre, UploadMethodPut, p, strings.NewReader(l.Holder), int64(len(l.Holder)), 1<<20, 0, nil,
+		map[string]string{
+			"Content-Type":              "text/plain",
+			"Seaweed-" + lockTagHolder:  l.Holder,
+			"Seaweed-" + lockTagToken:   strconv.FormatInt(l.Token, 10),
+			"Seaweed-" + lockTagExpires: l.exp.Format(time.RFC3339Nano),
+		}, nil)
+	if errors.Is(err, ErrPreconditionFailed) {
+		return nil, fmt.Errorf("%w: %s", ErrLockHeld, name)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// The filer is last-writer-wins; let concurrent writes land, then read back to detect them.
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-time.After(lockSettle(ttl)):
+	}
+	if err := l.verify(ctx); err != nil {
+		if errors.Is(err, ErrLockLost) {
+			return nil, fmt.Errorf("%w: %s", ErrLockHeld, name)
+		}
+		return nil, err
+	}
+
+	go l.renew()
+	return l, nil
+}
+
+// nextLockToken increments the fencing counter of the named lock and returns the new value. The
+// counter lives in its own entry so it survives Unlock; atLeast seeds it from an existing lock file.
+// 递增指定锁的 fencing 计数器并返回新值. 计数器保存在独立条目中, Unlock 后仍然保留; atLeast 用于从已有锁文件初始化.
+func (s *SeaweedFSService) nextLockToken(ctx context.Context, name string, atLeast int64) (int64, error) {
+	p := JoinPath(s.lockDir, lockTokenDir, name)
+
+	tags, err := s.GetTags(ctx, p)
+	if err != nil && !os.IsNotExist(err) {
+		return 0, err
+	}
+	n, _ := strconv.ParseInt(tags[lockTagToken], 10, 64)
+	if n < atLeast {
+		n = atLeast
+	}
+	n++
+
+	v := strconv.FormatInt(n, 10)
+	err = s.UploadWithOptions(ctx, UploadMethodPut, p, strings.NewReader(v), nil,
+		map[string]string{
+			"Content-Type":             "text/plain",
+			"Seaweed-" + lockTagToken:  v,
+			"Seaweed-" + lockTagHolder: s.lockHolder,
+		}, nil)
+	if err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// lockSettle returns how long TryLock waits before reading back the lock file: ttl/20, between
+// 10ms and 500ms. 返回 TryLock 读回锁文件前的等待时间: ttl/20, 介于 10ms 与 500ms 之间.
+func lockSettle(ttl time.Duration) time.Duration {
+	return min(max(ttl/20, 10*time.Millisecond), 500*time.Millisecond)
+}
+
+// verify checks that the lock file still records this lease. 检查锁文件仍记录当前租约.
+func (l *Lease) verify(ctx context.Context) error {
+	tags, err := l.s.GetTags(ctx, l.Path)
+	if err != nil {
+		return err
+	}
+	if tags[lockTagHolder] != l.Holder || tags[lockTagToken] != strconv.FormatInt(l.Token, 10) {
+		return fmt.Errorf("%w: %s taken over by %s", ErrLockLost, l.Name, tags[lockTagHolder])
+	}
+	return nil
+}
+
+// renew extends the lease every ttl/3 until stopped or the lease is lost.
+// 每隔 ttl/3 续约一次, 直到停止或租约丢失.
+func (l *Lease) renew() {
+	defer close(l.done)
+
+	t := time.NewTicker(l.ttl / 3)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-t.C:
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), l.ttl/3)
+		err := l.verify(ctx)
+		if err == nil {
+			exp := time.Now().Add(l.ttl)
+			err = l.s.SetTags(ctx, l.Path, FileTags{lockTagExpires: exp.Format(time.RFC3339Nano)})
+			if err == nil {
+				l.mu.Lock()
+				l.exp = exp
+				l.mu.Unlock()
+			}
+		}
+		cancel()
+
+		l.mu.Lock()
+		expired := !time.Now().Before(l.exp)
+		l.mu.Unlock()
+
+		// Transient errors are retried on the next tick while the lease is still valid.
+		if errors.Is(err, ErrLockLost) || os.IsNotExist(err) || (err != nil && expired) {
+			l.mu.Lock()
+			l.err = err
+			l.mu.Unlock()
+			close(l.lost)
+			return
+		}
+	}
+}
+
+// Lost is closed when the lease is lost; Err then reports why. 租约丢失时关闭, 之后可通过 Err 获取原因.
+func (l *Lease) Lost() <-chan struct{} {
+	return l.lost
+}
+
+// Err returns the error that caused the lease to be lost, or nil. 返回导致租约丢失的错误.
+func (l *Lease) Err() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.err
+}
+
+// Expires returns the current lease expiry. 返回当前租约过期时间.
+func (l *Lease) Expires() time.Time {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.exp
+}
+
+// Unlock stops renewal and releases the lock if it is still held by this lease.
+// 停止续约, 若锁仍由当前租约持有则释放.
+func (l *Lease) Unlock(ctx context.Context) error {
+	l.once.Do(func() { close(l.stop) })
+	<-l.done
+
+	select {
+	case <-l.lost:
+		return ErrLockLost
+	default:
+	}
+
+	if err := l.verify(ctx); err != nil {
+		return err
+	}
+	return l.s.deleteEntry(ctx, l.Path, nil)
+}
@@ -0,0 +1,144 @@
+package seaweedfs
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTryLockAcquireAndUnlock(t *testing.T) {
+	_, srv := newFakeFiler(t)
+	a := NewSeaweedFSService(srv.URL, WithLockHolder("a"))
+	b := NewSeaweedFSService(srv.URL, WithLockHolder("b"))
+	ctx := context.Background()
+
+	l, err := a.TryLock(ctx, "job", 300*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.TryLock(ctx, "job", time.Second); !errors.Is(err, ErrLockHeld) {
+		t.Fatalf("TryLock while held: got %v, want ErrLockHeld", err)
+	}
+
+	// Renewal must keep the lease alive past its ttl.
+	time.Sleep(600 * time.Millisecond)
+	if _, err := b.TryLock(ctx, "job", time.Second); !errors.Is(err, ErrLockHeld) {
+		t.Fatalf("TryLock after renewal: got %v, want ErrLockHeld", err)
+	}
+
+	if err := l.Unlock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	l2, err := b.TryLock(ctx, "job", time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l2.Unlock(ctx)
+	if l2.Token <= l.Token {
+		t.Fatalf("token after unlock: got %d, want > %d", l2.Token, l.Token)
+	}
+}
+
+func TestTryLockTakeover(t *testing.T) {
+	_, srv := newFakeFiler(t)
+	s := NewSeaweedFSService(srv.URL, WithLockHolder("b"))
+	ctx := context.Background()
+
+	// A holder that died without unlocking.
+	stale := map[string]string{
+		"Seaweed-" + lockTagHolder:  "a",
+		"Seaweed-" + lockTagToken:   "7",
+		"Seaweed-" + lockTagExpires: time.Now().Add(-time.Minute).Format(time.RFC3339Nano),
+	}
+	if err := s.UploadWithOptions(ctx, UploadMethodPut, "/.locks/job", strings.NewReader("a"), nil, stale, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	l, err := s.TryLock(ctx, "job", time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.Token != 8 {
+		t.Fatalf("takeover token: got %d, want 8", l.Token)
+	}
+	if err := l.Unlock(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// The counter survives Unlock, so the next holder is fenced off from the previous one.
+	l, err = s.TryLock(ctx, "job", time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Unlock(ctx)
+	if l.Token != 9 {
+		t.Fatalf("token after unlock: got %d, want 9", l.Token)
+	}
+}
+
+func TestTryLockContention(t *testing.T) {
+	f, srv := newFakeFiler(t)
+
+	// Hold both lock file writes until both contenders have checked the lock and found it free.
+	var mu sync.Mutex
+	arrived := 0
+	release := make(chan struct{})
+	f.hook = func(_ http.ResponseWriter, r *http.Request) bool {
+		if r.Method != http.MethodPut || r.URL.Path != "/.locks/job" || r.URL.RawQuery != "" {
+			return false
+		}
+		mu.Lock()
+		arrived++
+		if arrived == 2 {
+			close(release)
+		}
+		n := arrived
+		mu.Unlock()
+		if n <= 2 {
+			select {
+			case <-release:
+			case <-time.After(5 * time.Second):
+			}
+		}
+		return false
+	}
+
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	leases := make([]*Lease, 2)
+	errs := make([]error, 2)
+	for i, holder := range []string{"a", "b"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s := NewSeaweedFSService(srv.URL, WithLockHolder(holder))
+			leases[i], errs[i] = s.TryLock(ctx, "job", time.Second)
+		}()
+	}
+	wg.Wait()
+
+	won := 0
+	for i := range leases {
+		switch {
+		case errs[i] == nil:
+			won++
+			defer leases[i].Unlock(ctx)
+		case !errors.Is(errs[i], ErrLockHeld):
+			t.Fatalf("contender %d: %v", i, errs[i])
+		}
+	}
+	if won != 1 {
+		t.Fatalf("got %d holders, want 1", won)
+	}
+}
+
+func TestTryLockRejectsShortTTL(t *testing.T) {
+	s := NewSeaweedFSService("http://127.0.0.1:1")
+	if _, err := s.TryLock(context.Background(), "job", MinLockTTL/2); err == nil {
+		t.Fatal("TryLock accepted a ttl below MinLockTTL")
+	}
+}
@@ -0,0 +1,133 @@
+// Package seaweedfs provides a Go client for interacting with SeaweedFS.
+// It includes access to the master server for volume lookups.
+// 提供 SeaweedFS 的 Go 客户端, 包括访问 master 服务器以查询卷位置.
+package seaweedfs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// ErrNoMaster is returned by calls that need the master server when no master endpoint is configured.
+// 未配置 master 地址时, 需要 master 服务器的调用返回该错误.
+var ErrNoMaster = errors.New("master endpoint is not configured")
+
+// WithMasterEndpoint sets the master server used for volume lookups, e.g. "http://localhost:9333".
+// Requests to it are never JWT-signed.
+// 设置用于查询卷位置的 master 服务器地址, 如 "http://localhost:9333". 发往 master 的请求不进行 JWT 签名.
+func WithMasterEndpoint(endpoint string) Option {
+	return func(s *SeaweedFSService) {
+		s.masterEndpoint = strings.TrimRight(endpoint, "/")
+	}
+}
+
+// VolumeLocation is a volume server holding a replica of a volume. 持有卷副本的卷服务器.
+type VolumeLocation struct {
+	URL        string `json:"url"`                  // Internal address, host:port / 内部地址 host:port
+	PublicURL  string `json:"publicUrl"`            // Public address, host:port / 公开地址 host:port
+	DataCenter string `json:"dataCenter,omitempty"` // Data center, if reported / 数据中心 (如有)
+}
+
+// LookupVolume asks the master which volume servers hold volumeID. An unknown volume is reported as
+// a *StatusError with code 404 carrying the master's message.
+// 向 master 查询持有 volumeID 的卷服务器, 未知卷以携带 master 消息、状态码为 404 的 *StatusError 返回.
+func (s *SeaweedFSService) LookupVolume(ctx context.Context, volumeID string) ([]VolumeLocation, error) {
+	var out struct {
+		Locations []VolumeLocation `json:"locations"`
+		Error     string           `json:"error"`
+	}
+	err := s.masterJSON(ctx, "lookup volume", "/dir/lookup", url.Values{"volumeId": {volumeID}}, &out)
+	if err != nil {
+		return nil, err
+	}
+	if out.Error != "" {
+		return nil, &StatusError{Op: "lookup volume", Code: http.StatusNotFound, Status: http.StatusText(http.StatusNotFound), Body: out.Error}
+	}
+	return out.Locations, nil
+}
+
+// volumeLookup caches LookupVolume results for the duration of one operation.
+// 在一次操作期间缓存 LookupVolume 的结果.
+type volumeLookup struct {
+	s     *SeaweedFSService
+	mu    sync.Mutex
+	cache map[string][]VolumeLocation
+}
+
+func (l *volumeLookup) locations(ctx context.Context, volumeID string) ([]VolumeLocation, error) {
+	l.mu.Lock()
+	locs, ok := l.cache[volumeID]
+	l.mu.Unlock()
+	if ok {
+		return locs, nil
+	}
+	locs, err := l.s.LookupVolume(ctx, volumeID)
+	if err != nil {
+		return nil, err
+	}
+	l.mu.Lock()
+	l.cache[volumeID] = locs
+	l.mu.Unlock()
+	return locs, nil
+}
+
+// masterJSON sends a GET request to the master and decodes its JSON response into out.
+// 向 master 发送 GET 请求并将 JSON 响应解码到 out.
+func (s *SeaweedFSService) masterJSON(ctx context.Context, op, p string, query url.Values, out any) error {
+	if s.masterEndpoint == "" {
+		return ErrNoMaster
+	}
+	return s.getJSON(ctx, op, s.masterEndpoint+p, query, out)
+}
+
+// getJSON sends a GET request to u and decodes its JSON response into out. 向 u 发送 GET 请求并将 JSON 响应解码到 out.
+func (s *SeaweedFSService) getJSON(ctx context.Context, op, u string, query url.Values, out any) error {
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := s.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 400 {
+		return &StatusError{Op: op, Code: resp.StatusCode, Status: resp.Status, Body: string(b)}
+	}
+	return json.Unmarshal(b, out)
+}
+
+// volumeURL returns the URL of a volume server address, using the master's scheme.
+// 返回卷服务器地址的 URL, 使用与 master 相同的协议.
+func (s *SeaweedFSService) volumeURL(addr string) string {
+	if strings.Contains(addr, "://") {
+		return strings.TrimRight(addr, "/")
+	}
+	scheme := "http"
+	if strings.HasPrefix(s.masterEndpoint, "https://") {
+		scheme = "https"
+	}
+	return scheme + "://" + addr
+}
+
+// volumeID returns the volume part of a file id such as "3,01637037d6". 返回文件 ID (如 "3,01637037d6") 中的卷 ID 部分.
+func volumeID(fid string) string {
+	v, _, _ := strings.Cut(fid, ",")
+	return v
+}
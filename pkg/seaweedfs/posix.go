@@ -0,0 +1,240 @@
+// Package seaweedfs provides a Go client for interacting with SeaweedFS.
+// It includes POSIX metadata (mode, ownership, times, symlinks) and extended attributes.
+// 提供 SeaweedFS 的 Go 客户端, 包括 POSIX 元数据 (权限、所有者、时间、符号链接) 与扩展属性.
+package seaweedfs
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The filer HTTP API cannot change the attributes of an existing entry, so changes are stored as
+// tags and applied over the filer's own values by Stat.
+// filer HTTP API 无法修改已有条目的属性, 因此修改以标签形式保存, 由 Stat 覆盖到 filer 自身的值上.
+const (
+	posixTagMode    = "Posix-Mode"
+	posixTagUid     = "Posix-Uid"
+	posixTagGid     = "Posix-Gid"
+	posixTagAtime   = "Posix-Atime"
+	posixTagSymlink = "Symlink-Target"
+
+	// xattrTagPrefix precedes the hex-encoded attribute name; header names are case-insensitive
+	// and restricted, so names cannot be used as-is. Values are base64-encoded.
+	xattrTagPrefix = "Xattr-"
+)
+
+// ErrNoXattr is returned when an extended attribute does not exist. 扩展属性不存在时返回.
+var ErrNoXattr = errors.New("extended attribute not found")
+
+// applyPosixOverlay applies the attribute tags found in the filer's Extended map to stat.
+// 将 filer Extended 中的属性标签应用到 stat.
+func applyPosixOverlay(stat *SeaweedStat, ext map[string][]byte) {
+	tag := func(name string) (string, bool) {
+		v, ok := ext[http.CanonicalHeaderKey("Seaweed-"+name)]
+		return string(v), ok
+	}
+
+	if v, ok := tag(posixTagMode); ok {
+		if perm, err := strconv.ParseUint(v, 8, 32); err == nil {
+			stat.Mode = stat.Mode&^uint32(os.ModePerm) | uint32(perm)&uint32(os.ModePerm)
+		}
+	}
+	if v, ok := tag(posixTagUid); ok {
+		if id, err := strconv.ParseUint(v, 10, 32); err == nil {
+			stat.Uid = uint32(id)
+		}
+	}
+	if v, ok := tag(posixTagGid); ok {
+		if id, err := strconv.ParseUint(v, 10, 32); err == nil {
+			stat.Gid = uint32(id)
+		}
+	}
+	if v, ok := tag(mtimeTag); ok {
+		if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+			stat.Mtime = t
+		}
+	}
+	stat.Atime = stat.Mtime
+	if v, ok := tag(posixTagAtime); ok {
+		if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+			stat.Atime = t
+		}
+	}
+	if v, ok := tag(posixTagSymlink); ok && stat.SymlinkTarget == "" {
+		stat.SymlinkTarget = v
+	}
+	if stat.SymlinkTarget != "" {
+		stat.Mode |= uint32(os.ModeSymlink)
+	}
+}
+
+// Chmod changes the permission bits of p. Only os.ModePerm bits are kept.
+// 修改 p 的权限位, 仅保留 os.ModePerm 范围内的位.
+func (s *SeaweedFSService) Chmod(ctx context.Context, p string, mode os.FileMode) error {
+	return s.setAttrTags(ctx, "chmod", p, FileTags{posixTagMode: strconv.FormatUint(uint64(mode.Perm()), 8)})
+}
+
+// Chown changes the owner of p. A negative uid or gid leaves that value unchanged, like os.Chown.
+// 修改 p 的所有者, 与 os.Chown 一致, uid 或 gid 为负数时保持不变.
+func (s *SeaweedFSService) Chown(ctx context.Context, p string, uid, gid int) error {
+	tags := make(FileTags)
+	if uid >= 0 {
+		tags[posixTagUid] = strconv.Itoa(uid)
+	}
+	if gid >= 0 {
+		tags[posixTagGid] = strconv.Itoa(gid)
+	}
+	return s.setAttrTags(ctx, "chown", p, tags)
+}
+
+// Chtimes changes the access and modification times of p. A zero time leaves that value unchanged,
+// like os.Chtimes. The modification time is the same Mtime tag that Replicate and ImportTar set.
+// 修改 p 的访问时间与修改时间, 与 os.Chtimes 一致, 零值时间保持不变. 修改时间与 Replicate、ImportTar 使用同一个 Mtime 标签.
+func (s *SeaweedFSService) Chtimes(ctx context.Context, p string, atime, mtime time.Time) error {
+	tags := make(FileTags)
+	if !atime.IsZero() {
+		tags[posixTagAtime] = atime.UTC().Format(time.RFC3339Nano)
+	}
+	if !mtime.IsZero() {
+		tags[mtimeTag] = mtime.UTC().Format(time.RFC3339Nano)
+	}
+	return s.setAttrTags(ctx, "chtimes", p, tags)
+}
+
+// setAttrTags sets attribute tags on an existing entry, reporting a missing one as os.ErrNotExist.
+// 在已存在的条目上设置属性标签, 条目不存在时返回 os.ErrNotExist.
+func (s *SeaweedFSService) setAttrTags(ctx context.Context, op, p string, tags FileTags) error {
+	if len(tags) == 0 {
+		return nil
+	}
+	err := s.SetTags(ctx, p, tags)
+	var se *StatusError
+	if errors.As(err, &se) && se.Code == http.StatusNotFound {
+		return fmt.Errorf("%s %s: %w", op, p, os.ErrNotExist)
+	}
+	return err
+}
+
+// Symlink creates link as a symbolic link to target. The filer HTTP API cannot create real symlink
+// entries, so link is an empty file carrying the target, which Stat and Readlink report as a symlink.
+// Reading link does not follow it. An existing link is reported as os.ErrExist.
+// 创建指向 target 的符号链接 link. filer HTTP API 无法创建真正的符号链接条目, 因此 link 是记录目标的空文件,
+// Stat 与 Readlink 会将其视为符号链接, 读取 link 不会跟随链接. link 已存在时返回 os.ErrExist.
+func (s *SeaweedFSService) Symlink(ctx context.Context, target, link string) error {
+	if target == "" {
+		return fmt.Errorf("symlink %s: empty target", link)
+	}
+	uo := &UploadOptions{Mode: os.ModePerm, Tags: FileTags{posixTagSymlink: target}}
+	query, headers, err := uo.Encode()
+	if err != nil {
+		return err
+	}
+	err = s.UploadIf(ctx, Precondition{CreateOnly: true}, UploadMethodPut, link, strings.NewReader(""), 0, 1<<20, 0, query, headers, nil)
+	if errors.Is(err, ErrPreconditionFailed) {
+		return fmt.Errorf("symlink %s: %w", link, os.ErrExist)
+	}
+	return err
+}
+
+// Readlink returns the target of the symbolic link p, or an error wrapping os.ErrInvalid if p is not one.
+// 返回符号链接 p 的目标, p 不是符号链接时返回包装 os.ErrInvalid 的错误.
+func (s *SeaweedFSService) Readlink(ctx context.Context, p string) (string, error) {
+	stat, err := s.Stat(ctx, p, false)
+	if err != nil {
+		return "", err
+	}
+	if stat.SymlinkTarget == "" {
+		return "", fmt.Errorf("readlink %s: not a symlink: %w", p, os.ErrInvalid)
+	}
+	return stat.SymlinkTarget, nil
+}
+
+// xattrTag returns the tag name under which the filer stores attribute name.
+// 返回 filer 存储属性 name 所用的标签名.
+func xattrTag(name string) string {
+	key := http.CanonicalHeaderKey("Seaweed-" + xattrTagPrefix + hex.EncodeToString([]byte(name)))
+	return strings.TrimPrefix(key, "Seaweed-")
+}
+
+// SetXattr sets the extended attribute name of p to value. 将 p 的扩展属性 name 设置为 value.
+func (s *SeaweedFSService) SetXattr(ctx context.Context, p, name string, value []byte) error {
+	if name == "" {
+		return fmt.Errorf("setxattr %s: empty attribute name", p)
+	}
+	return s.setAttrTags(ctx, "setxattr", p, FileTags{xattrTag(name): base64.StdEncoding.EncodeToString(value)})
+}
+
+// GetXattr returns the extended attribute name of p, or ErrNoXattr. 返回 p 的扩展属性 name, 不存在时返回 ErrNoXattr.
+func (s *SeaweedFSService) GetXattr(ctx context.Context, p, name string) ([]byte, error) {
+	attrs, err := s.xattrs(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+	v, ok := attrs[name]
+	if !ok {
+		return nil, fmt.Errorf("getxattr %s %s: %w", p, name, ErrNoXattr)
+	}
+	return v, nil
+}
+
+// ListXattr returns the sorted names of the extended attributes of p. 返回 p 的扩展属性名称 (已排序).
+func (s *SeaweedFSService) ListXattr(ctx context.Context, p string) ([]string, error) {
+	attrs, err := s.xattrs(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(attrs))
+	for name := range attrs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// RemoveXattr removes the extended attribute name of p, or returns ErrNoXattr if it is not set.
+// 删除 p 的扩展属性 name, 未设置时返回 ErrNoXattr.
+func (s *SeaweedFSService) RemoveXattr(ctx context.Context, p, name string) error {
+	attrs, err := s.xattrs(ctx, p)
+	if err != nil {
+		return err
+	}
+	if _, ok := attrs[name]; !ok {
+		return fmt.Errorf("removexattr %s %s: %w", p, name, ErrNoXattr)
+	}
+	return s.DeleteTags(ctx, p, xattrTag(name))
+}
+
+// xattrs decodes the extended attributes of p from its tags; undecodable tags are ignored.
+// 从 p 的标签中解码扩展属性, 无法解码的标签会被忽略.
+func (s *SeaweedFSService) xattrs(ctx context.Context, p string) (map[string][]byte, error) {
+	tags, err := s.GetTags(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+	attrs := make(map[string][]byte)
+	for k, v := range tags {
+		enc, ok := strings.CutPrefix(k, xattrTagPrefix)
+		if !ok {
+			continue
+		}
+		name, err := hex.DecodeString(enc)
+		if err != nil {
+			continue
+		}
+		value, err := base64.StdEncoding.DecodeString(v)
+		if err != nil {
+			continue
+		}
+		attrs[string(name)] = value
+	}
+	return attrs, nil
+}
@@ -0,0 +1,123 @@
+package seaweedfs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestXattrEncoding(t *testing.T) {
+	_, srv := newFakeFiler(t)
+	s := NewSeaweedFSService(srv.URL)
+	ctx := context.Background()
+
+	if err := putBytes(s, "/f", 1); err != nil {
+		t.Fatal(err)
+	}
+	// Names that are not valid or not case-preserved as header names, and binary values.
+	attrs := map[string][]byte{
+		"user.Mime_Type":   []byte("text/plain"),
+		"security.selinux": {0, 1, 2, 0xff, '\n'},
+		"\xe4\xb8\xad":     []byte("中文"),
+		"user.empty":       {},
+	}
+	for name, v := range attrs {
+		if err := s.SetXattr(ctx, "/f", name, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.SetTags(ctx, "/f", FileTags{"Owner": "ann"}); err != nil {
+		t.Fatal(err)
+	}
+
+	names, err := s.ListXattr(ctx, "/f")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"security.selinux", "user.Mime_Type", "user.empty", "\xe4\xb8\xad"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("names: got %q, want %q", names, want)
+	}
+	for name, want := range attrs {
+		got, err := s.GetXattr(ctx, "/f", name)
+		if err != nil || !bytes.Equal(got, want) {
+			t.Fatalf("%q: got %q, %v, want %q", name, got, err, want)
+		}
+	}
+	if _, err := s.GetXattr(ctx, "/f", "user.mime_type"); !errors.Is(err, ErrNoXattr) {
+		t.Fatalf("names must be case-sensitive: got %v", err)
+	}
+
+	if err := s.RemoveXattr(ctx, "/f", "user.Mime_Type"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.RemoveXattr(ctx, "/f", "user.Mime_Type"); !errors.Is(err, ErrNoXattr) {
+		t.Fatalf("second remove: got %v, want ErrNoXattr", err)
+	}
+	if tags, err := s.GetTags(ctx, "/f"); err != nil || tags["Owner"] != "ann" {
+		t.Fatalf("unrelated tags: %v, %v", tags, err)
+	}
+	if err := s.SetXattr(ctx, "/missing", "user.a", nil); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("missing entry: got %v, want os.ErrNotExist", err)
+	}
+}
+
+func TestPosixAttributes(t *testing.T) {
+	_, srv := newFakeFiler(t)
+	s := NewSeaweedFSService(srv.URL)
+	ctx := context.Background()
+
+	if err := putBytes(s, "/f", 1); err != nil {
+		t.Fatal(err)
+	}
+	mtime := time.Date(2020, 1, 2, 3, 4, 5, 6, time.UTC)
+	atime := mtime.Add(time.Hour)
+	if err := s.Chmod(ctx, "/f", 0o4750); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Chown(ctx, "/f", 1000, 100); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Chown(ctx, "/f", -1, 200); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Chtimes(ctx, "/f", atime, mtime); err != nil {
+		t.Fatal(err)
+	}
+
+	stat, err := s.Stat(ctx, "/f", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := os.FileMode(stat.Mode).Perm(); perm != 0o750 {
+		t.Fatalf("mode: got %o, want 750", perm)
+	}
+	if stat.Uid != 1000 || stat.Gid != 200 {
+		t.Fatalf("owner: got %d:%d, want 1000:200", stat.Uid, stat.Gid)
+	}
+	if !stat.Mtime.Equal(mtime) || !stat.Atime.Equal(atime) {
+		t.Fatalf("times: got %v / %v", stat.Mtime, stat.Atime)
+	}
+	if err := s.Chmod(ctx, "/missing", 0o600); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("missing entry: got %v, want os.ErrNotExist", err)
+	}
+
+	if err := s.Symlink(ctx, "../target", "/link"); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := s.Readlink(ctx, "/link"); err != nil || got != "../target" {
+		t.Fatalf("readlink: got %q, %v", got, err)
+	}
+	if stat, err := s.Stat(ctx, "/link", false); err != nil || os.FileMode(stat.Mode)&os.ModeSymlink == 0 {
+		t.Fatalf("symlink mode: %+v, %v", stat, err)
+	}
+	if _, err := s.Readlink(ctx, "/f"); !errors.Is(err, os.ErrInvalid) {
+		t.Fatalf("readlink of a file: got %v, want os.ErrInvalid", err)
+	}
+	if err := s.Symlink(ctx, "other", "/link"); !errors.Is(err, os.ErrExist) {
+		t.Fatalf("existing link: got %v, want os.ErrExist", err)
+	}
+}
@@ -0,0 +1,183 @@
+// Package seaweedfs provides a Go client for interacting with SeaweedFS.
+// It includes conditional operations (create-only, if-mtime-unchanged, if-match) for optimistic concurrency.
+// 提供 SeaweedFS 的 Go 客户端, 包括用于乐观并发控制的条件操作 (仅创建、修改时间未变、匹配校验值).
+package seaweedfs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+// ErrPreconditionFailed is returned when a conditional operation's precondition does not hold.
+// 条件操作的前置条件不满足时返回.
+var ErrPreconditionFailed = errors.New("precondition failed")
+
+// Precondition describes the state an entry must be in for a conditional operation to proceed.
+// The filer does not enforce preconditions on writes, so they are checked with Stat right before
+// the operation; this narrows but does not close the race window between concurrent writers.
+// 描述条件操作执行前条目必须满足的状态. filer 不会对写操作强制前置条件,
+// 因此在操作前通过 Stat 检查, 这会缩小但无法完全消除并发写入之间的竞争窗口.
+type Precondition struct {
+	CreateOnly       bool      // Fail if the entry exists / 条目已存在时失败
+	IfMtimeUnchanged time.Time // Fail unless the entry's mtime equals this (second precision) / 修改时间不等于该值时失败 (秒精度)
+	IfMatch          string    // Fail unless the entry's MD5 (hex or base64) or ETag equals this / MD5 (hex 或 base64) 或 ETag 不匹配时失败
+}
+
+// IsZero reports whether no condition is set. 判断是否未设置任何条件.
+func (pre Precondition) IsZero() bool {
+	return !pre.CreateOnly && pre.IfMtimeUnchanged.IsZero() && pre.IfMatch == ""
+}
+
+// headers returns the standard HTTP precondition headers for filers that honour them.
+// 返回标准 HTTP 前置条件头, 供支持它们的 filer 使用.
+func (pre Precondition) headers() map[string]string {
+	h := make(map[string]string)
+	if pre.CreateOnly {
+		h["If-None-Match"] = "*"
+	}
+	if !pre.IfMtimeUnchanged.IsZero() {
+		h["If-Unmodified-Since"] = pre.IfMtimeUnchanged.UTC().Format(http.TimeFormat)
+	}
+	if pre.IfMatch != "" {
+		h["If-Match"] = `"` + strings.Trim(pre.IfMatch, `"`) + `"`
+	}
+	return h
+}
+
+// withoutPreconditions returns headers without the HTTP precondition headers. UploadLarge sends the
+// remaining chunks with it, since chunk one already created or changed the entry.
+// 返回去掉 HTTP 前置条件头的 headers. UploadLarge 的后续分片使用它, 因为第一个分片已创建或修改了条目.
+func withoutPreconditions(headers map[string]string) map[string]string {
+	out := make(map[string]string, len(headers))
+	for k, v := range headers {
+		switch http.CanonicalHeaderKey(k) {
+		case "If-None-Match", "If-Match", "If-Unmodified-Since", "If-Modified-Since":
+		default:
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// CheckPrecondition verifies pre against the current state of p and returns an error
+// matching ErrPreconditionFailed when it does not hold.
+// 根据 p 的当前状态校验 pre, 不满足时返回可匹配 ErrPreconditionFailed 的错误.
+func (s *SeaweedFSService) CheckPrecondition(ctx context.Context, p string, pre Precondition) error {
+	if pre.IsZero() {
+		return nil
+	}
+
+	stat, err := s.Stat(ctx, p, false)
+	if os.IsNotExist(err) {
+		if !pre.IfMtimeUnchanged.IsZero() || pre.IfMatch != "" {
+			return fmt.Errorf("%w: %s does not exist", ErrPreconditionFailed, p)
+		}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if pre.CreateOnly {
+		return fmt.Errorf("%w: %s already exists", ErrPreconditionFailed, p)
+	}
+	if !pre.IfMtimeUnchanged.IsZero() && stat.Mtime.Unix() != pre.IfMtimeUnchanged.Unix() {
+		return fmt.Errorf("%w: %s modified at %s", ErrPreconditionFailed, p, FormatSeaweedTime(stat.Mtime))
+	}
+	if pre.IfMatch != "" {
+		ok, err := s.matches(ctx, p, stat, pre.IfMatch)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("%w: %s does not match %s", ErrPreconditionFailed, p, pre.IfMatch)
+		}
+	}
+	return nil
+}
+
+// matches compares want with the entry's MD5, falling back to the ETag response header.
+// 将 want 与条目的 MD5 比较, 不匹配时回退到 ETag 响应头.
+func (s *SeaweedFSService) matches(ctx context.Context, p string, stat *SeaweedStat, want string) (bool, error) {
+	want = strings.Trim(want, `"`)
+	if stat.Md5 != "" && md5Hex(stat.Md5) == md5Hex(want) {
+		return true, nil
+	}
+
+	header, err := s.head(ctx, p, "stat")
+	if err != nil {
+		return false, err
+	}
+	etag := strings.TrimPrefix(header.Get("ETag"), "W/")
+	return etag != "" && strings.Trim(etag, `"`) == want, nil
+}
+
+// UploadIf uploads r to dst only if pre holds for dst.
+// 仅当 dst 满足 pre 时上传 r.
+func (s *SeaweedFSService) UploadIf(
+	ctx context.Context,
+	pre Precondition, // Precondition on dst / dst 的前置条件
+	method UploadMethod, // HTTP method / HTTP 方法
+	dst string, // Destination path / 目标路径
+	r io.Reader, // Source reader / 数据源
+	size int64, // Total size / 数据总大小
+	largeThreshold int64, // Threshold for large upload / 大文件阈值
+	chunkSize int64, // Chunk size / 分片大小
+	opts map[string]string, // Optional query parameters / 可选查询参数
+	headers map[string]string, // Optional HTTP headers / 可选 HTTP 头
+	progress ProgressFunc, // Callback for progress / 进度回调
+) error {
+	if err := s.CheckPrecondition(ctx, dst, pre); err != nil {
+		return err
+	}
+
+	h := pre.headers()
+	for k, v := range headers {
+		h[k] = v
+	}
+	return s.UploadReaderSmart(ctx, method, dst, r, size, largeThreshold, chunkSize, opts, h, progress)
+}
+
+// DeleteIf deletes p only if pre holds for p. 仅当 p 满足 pre 时删除.
+func (s *SeaweedFSService) DeleteIf(ctx context.Context, pre Precondition, p string, extra map[string]string) error {
+	if err := s.CheckPrecondition(ctx, p, pre); err != nil {
+		return err
+	}
+	return s.Delete(ctx, p, extra)
+}
+
+// MoveIf moves from to to only if pre holds. CreateOnly is checked against the destination,
+// IfMtimeUnchanged and IfMatch against the source.
+// 仅当满足 pre 时移动. CreateOnly 针对目标检查, IfMtimeUnchanged 与 IfMatch 针对源检查.
+func (s *SeaweedFSService) MoveIf(ctx context.Context, pre Precondition, from, to string) error {
+	if pre.CreateOnly {
+		dst := to
+		if strings.HasSuffix(dst, "/") {
+			dst += path.Base(from)
+		}
+		if err := s.CheckPrecondition(ctx, dst, Precondition{CreateOnly: true}); err != nil {
+			return err
+		}
+	}
+	src := pre
+	src.CreateOnly = false
+	if err := s.CheckPrecondition(ctx, from, src); err != nil {
+		return err
+	}
+	return s.Move(ctx, from, to)
+}
+
+// SetTagsIf sets tags on p only if pre holds for p. 仅当 p 满足 pre 时设置标签.
+func (s *SeaweedFSService) SetTagsIf(ctx context.Context, pre Precondition, p string, tags FileTags) error {
+	if err := s.CheckPrecondition(ctx, p, pre); err != nil {
+		return err
+	}
+	return s.SetTags(ctx, p, tags)
+}
@@ -0,0 +1,381 @@
+// Package seaweedfs provides a Go client for interacting with SeaweedFS.
+// It includes client-side directory quotas stored in directory tags.
+// 提供 SeaweedFS 的 Go 客户端, 包括存储在目录标签中的客户端目录配额.
+package seaweedfs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/GoFurry/seaweedfs-sdk-go/internal/util"
+)
+
+// quotaTag is the directory tag holding the byte limit of a quota. 保存配额字节上限的目录标签.
+const quotaTag = "Quota-Bytes"
+
+// ErrQuotaExceeded is matched by errors.Is for uploads rejected by a directory quota.
+// 被目录配额拒绝的上传可通过 errors.Is 匹配该错误.
+var ErrQuotaExceeded = errors.New("quota exceeded")
+
+// QuotaError reports an upload that would take a directory over its quota. 表示会使目录超出配额的上传.
+type QuotaError struct {
+	Dir       string // Directory holding the quota / 设置配额的目录
+	Limit     int64  // Byte limit / 字节上限
+	Used      int64  // Bytes used, as last measured / 最近统计的已用字节
+	Reserved  int64  // Bytes reserved by in-flight uploads / 进行中的上传预留的字节
+	Requested int64  // Bytes the upload asked for / 本次上传请求的字节
+}
+
+func (e *QuotaError) Error() string {
+	return fmt.Sprintf("quota exceeded for %s: limit %d, used %d, reserved %d, requested %d",
+		e.Dir, e.Limit, e.Used, e.Reserved, e.Requested)
+}
+
+// Is reports the error as ErrQuotaExceeded. 将该错误视为 ErrQuotaExceeded.
+func (e *QuotaError) Is(target error) bool {
+	return target == ErrQuotaExceeded
+}
+
+// QuotaUsage is the state of one directory quota. 单个目录配额的状态.
+type QuotaUsage struct {
+	Dir      string `json:"dir"`      // Directory holding the quota / 设置配额的目录
+	Limit    int64  `json:"limit"`    // Byte limit, 0 if none / 字节上限, 未设置时为 0
+	Used     int64  `json:"used"`     // Bytes used / 已用字节
+	Reserved int64  `json:"reserved"` // Bytes reserved by in-flight uploads of this service / 本服务进行中的上传预留的字节
+}
+
+// Remaining returns the bytes that can still be uploaded, or -1 without a limit.
+// 返回仍可上传的字节数, 未设置上限时返回 -1.
+func (u *QuotaUsage) Remaining() int64 {
+	if u.Limit <= 0 {
+		return -1
+	}
+	if r := u.Limit - u.Used - u.Reserved; r > 0 {
+		return r
+	}
+	return 0
+}
+
+// WithQuotas enforces directory quotas on uploads. Before an upload, every ancestor directory with
+// a quota (see SetQuota) is checked against its usage from GetDirUsage plus the bytes reserved by
+// in-flight uploads, and the upload fails with a *QuotaError if it would exceed the limit. Overwriting
+// a file only needs room for the growth. Limits and usage are cached for cacheTTL (30s if <= 0);
+// deletes, moves and copies through this service mark the usage of affected quotas stale. Quotas are
+// shared through the tags, but reservations are per service.
+// 对上传启用目录配额. 上传前会检查每个设置了配额 (见 SetQuota) 的上级目录, 以 GetDirUsage 统计的用量加上
+// 进行中上传的预留字节判断, 超出上限时以 *QuotaError 失败. 覆盖文件只需为增长的部分预留空间. 上限与用量缓存
+// cacheTTL (<= 0 时为 30 秒), 通过本服务的删除、移动与复制会使相关配额的用量失效. 配额通过标签共享,
+// 但预留仅在当前服务内有效.
+func WithQuotas(cacheTTL time.Duration) Option {
+	return func(s *SeaweedFSService) {
+		if cacheTTL <= 0 {
+			cacheTTL = 30 * time.Second
+		}
+		s.quotas = &quotaManager{s: s, ttl: cacheTTL, dirs: make(map[string]*quotaState)}
+	}
+}
+
+// SetQuota sets the byte limit of directory dir. A limit <= 0 removes the quota.
+// 设置目录 dir 的字节上限, limit <= 0 时移除配额.
+func (s *SeaweedFSService) SetQuota(ctx context.Context, dir string, limit int64) error {
+	dir = quotaDir(dir)
+	var err error
+	if limit <= 0 {
+		err = s.DeleteTags(ctx, dir, quotaTag)
+	} else {
+		err = s.SetTags(ctx, dir, FileTags{quotaTag: strconv.FormatInt(limit, 10)})
+	}
+	if err != nil {
+		return err
+	}
+	s.quotas.forget(dir)
+	return nil
+}
+
+// GetQuota returns the byte limit of directory dir, or 0 if it has no quota.
+// 返回目录 dir 的字节上限, 未设置配额时返回 0.
+func (s *SeaweedFSService) GetQuota(ctx context.Context, dir string) (int64, error) {
+	e, err := s.entry(ctx, quotaDir(dir))
+	if err != nil {
+		return 0, err
+	}
+	v := strings.TrimSpace(string(e.Extended["Seaweed-"+quotaTag]))
+	if v == "" {
+		return 0, nil
+	}
+	limit, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("quota of %s: invalid limit %q: %w", dir, v, err)
+	}
+	return limit, nil
+}
+
+// GetQuotaUsage returns the limit and freshly measured usage of directory dir, and refreshes the
+// cached usage when quotas are enabled.
+// 返回目录 dir 的上限与重新统计的用量, 启用配额时同时刷新缓存的用量.
+func (s *SeaweedFSService) GetQuotaUsage(ctx context.Context, dir string) (*QuotaUsage, error) {
+	dir = quotaDir(dir)
+	limit, err := s.GetQuota(ctx, dir)
+	if err != nil {
+		return nil, err
+	}
+	usage, err := s.GetDirUsage(ctx, dir)
+	if err != nil {
+		return nil, err
+	}
+	return s.quotas.update(dir, limit, usage.TotalSize), nil
+}
+
+// quotaDir normalizes a directory path for use as a quota key. 规范化目录路径, 用作配额键.
+func quotaDir(dir string) string {
+	dir = strings.TrimRight(util.NormalizePath(dir), "/")
+	if dir == "" {
+		return "/"
+	}
+	return dir
+}
+
+// quotaManager caches quota limits, usage and reservations. A nil manager enforces nothing.
+// 缓存配额上限、用量与预留, nil 管理器不做任何限制.
+type quotaManager struct {
+	s    *SeaweedFSService
+	ttl  time.Duration
+	mu   sync.Mutex
+	dirs map[string]*quotaState
+}
+
+// quotaState is the cached state of one directory; limit 0 means no quota.
+// 单个目录的缓存状态, limit 为 0 表示无配额.
+type quotaState struct {
+	dir      string
+	limit    int64
+	limitAt  time.Time
+	used     int64
+	usedAt   time.Time
+	reserved int64
+}
+
+// state returns the cached state of dir, refreshing a stale limit or usage first.
+// 返回 dir 的缓存状态, 上限或用量过期时先刷新.
+func (m *quotaManager) state(ctx context.Context, dir string) (*quotaState, error) {
+	m.mu.Lock()
+	st, ok := m.dirs[dir]
+	if !ok {
+		st = &quotaState{dir: dir}
+		m.dirs[dir] = st
+	}
+	now := time.Now()
+	staleLimit := now.Sub(st.limitAt) > m.ttl
+	m.mu.Unlock()
+
+	if staleLimit {
+		limit, err := m.s.GetQuota(ctx, dir)
+		if errors.Is(err, os.ErrNotExist) {
+			limit, err = 0, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		m.mu.Lock()
+		st.limit, st.limitAt = limit, now
+		m.mu.Unlock()
+	}
+
+	m.mu.Lock()
+	staleUsage := st.limit > 0 && now.Sub(st.usedAt) > m.ttl
+	m.mu.Unlock()
+	if staleUsage {
+		usage, err := m.s.GetDirUsage(ctx, dir)
+		if err != nil {
+			return nil, err
+		}
+		m.mu.Lock()
+		st.used, st.usedAt = usage.TotalSize, now
+		m.mu.Unlock()
+	}
+	return st, nil
+}
+
+// begin checks the quotas of every ancestor directory of dst and reserves size bytes, or none if
+// size is unknown (< 0). If replace is set, the upload overwrites dst, whose current size is
+// credited against the reservation. It returns nil if no quota applies.
+// 检查 dst 所有上级目录的配额并预留 size 字节, size 未知 (< 0) 时不预留. replace 为 true 时上传会覆盖 dst,
+// 其当前大小从预留中抵扣. 没有适用的配额时返回 nil.
+func (m *quotaManager) begin(ctx context.Context, dst string, size int64, replace bool) (*quotaReservation, error) {
+	if m == nil {
+		return nil, nil
+	}
+
+	res := &quotaReservation{m: m}
+	for dir := quotaDir(path.Dir(quotaDir(dst))); ; dir = path.Dir(dir) {
+		st, err := m.state(ctx, dir)
+		if err != nil {
+			return nil, err
+		}
+		m.mu.Lock()
+		limited := st.limit > 0
+		m.mu.Unlock()
+		if limited {
+			res.states = append(res.states, st)
+		}
+		if dir == "/" {
+			break
+		}
+	}
+	if len(res.states) == 0 {
+		return nil, nil
+	}
+	if replace {
+		stat, err := m.s.Stat(ctx, dst, false)
+		switch {
+		case err == nil && !stat.IsDir:
+			res.credit = stat.Size
+		case err != nil && !errors.Is(err, os.ErrNotExist):
+			return nil, err
+		}
+	}
+	if size > 0 {
+		if err := res.reserve(size); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// update stores freshly measured usage of dir and returns its state. 保存 dir 的最新用量并返回其状态.
+func (m *quotaManager) update(dir string, limit, used int64) *QuotaUsage {
+	if m == nil {
+		return &QuotaUsage{Dir: dir, Limit: limit, Used: used}
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	st, ok := m.dirs[dir]
+	if !ok {
+		st = &quotaState{dir: dir}
+		m.dirs[dir] = st
+	}
+	now := time.Now()
+	st.limit, st.limitAt, st.used, st.usedAt = limit, now, used, now
+	return &QuotaUsage{Dir: dir, Limit: limit, Used: used, Reserved: st.reserved}
+}
+
+// invalidate marks the usage of quotas containing, or contained in, p as stale.
+// 将包含 p 或被 p 包含的配额用量标记为过期.
+func (m *quotaManager) invalidate(p string) {
+	if m == nil {
+		return
+	}
+	p = quotaDir(p)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for dir, st := range m.dirs {
+		if pathWithin(p, dir) || pathWithin(dir, p) {
+			st.usedAt = time.Time{}
+		}
+	}
+}
+
+// forget drops the cached limit of dir after it changed. 上限变化后丢弃 dir 的缓存.
+func (m *quotaManager) forget(dir string) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if st, ok := m.dirs[dir]; ok {
+		st.limitAt = time.Time{}
+	}
+}
+
+// pathWithin reports whether p is dir or below it. 判断 p 是否为 dir 或位于其下.
+func pathWithin(p, dir string) bool {
+	return dir == "/" || p == dir || strings.HasPrefix(p, dir+"/")
+}
+
+// quotaReservation holds the bytes reserved by one upload. 单次上传预留的字节.
+type quotaReservation struct {
+	m      *quotaManager
+	states []*quotaState
+	n      int64 // Bytes reserved / 已预留的字节
+	credit int64 // Unused bytes of the overwritten file / 被覆盖文件尚未抵扣的字节
+}
+
+// replaces reports whether an upload with query parameters opts overwrites the destination
+// rather than appending to it. 判断使用查询参数 opts 的上传是覆盖目标而非追加.
+func replaces(opts map[string]string) bool {
+	_, offset := opts["offset"]
+	return opts["op"] != "append" && !offset
+}
+
+// reserve reserves n more bytes on every quota, or none if one of them would be exceeded. Bytes
+// that replace the overwritten file are taken from the credit first.
+// 在每个配额上再预留 n 字节, 任一配额会超出时不做预留. 替换被覆盖文件的字节优先从抵扣额中扣除.
+func (r *quotaReservation) reserve(n int64) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	c := min(n, r.credit)
+	n -= c
+	for _, st := range r.states {
+		if n > 0 && st.used+st.reserved+n > st.limit {
+			return &QuotaError{Dir: st.dir, Limit: st.limit, Used: st.used, Reserved: st.reserved, Requested: r.n + n}
+		}
+	}
+	for _, st := range r.states {
+		st.reserved += n
+	}
+	r.n += n
+	r.credit -= c
+	return nil
+}
+
+// done releases the reservation and, if the upload succeeded, counts its bytes as used until the
+// next refresh; an overwritten file that shrank gives back the difference. A nil reservation is a no-op.
+// 释放预留, 上传成功时在下次刷新前将其字节计入已用, 被覆盖的文件变小时归还差值. nil 预留不做任何操作.
+func (r *quotaReservation) done(ok bool) {
+	if r == nil {
+		return
+	}
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	for _, st := range r.states {
+		st.reserved -= r.n
+		if ok {
+			st.used += r.n - r.credit
+		}
+	}
+	r.n, r.credit = 0, 0
+}
+
+// quotaReader reserves bytes as they are read, for uploads of unknown size.
+// 在读取时逐步预留字节, 用于大小未知的上传.
+type quotaReader struct {
+	r   io.Reader
+	res *quotaReservation
+}
+
+func (q *quotaReader) Read(p []byte) (int, error) {
+	n, err := q.r.Read(p)
+	if n > 0 {
+		if rerr := q.res.reserve(int64(n)); rerr != nil {
+			return 0, rerr
+		}
+	}
+	return n, err
+}
+
+// readerLen returns the remaining length of in-memory readers, or -1 if unknown.
+// 返回内存读取器的剩余长度, 未知时返回 -1.
+func readerLen(r io.Reader) int64 {
+	if l, ok := r.(interface{ Len() int }); ok {
+		return int64(l.Len())
+	}
+	return -1
+}
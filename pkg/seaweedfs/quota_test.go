@@ -0,0 +1,227 @@
+package seaweedfs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newQuotaService returns a service with quotas enabled and a 100 byte quota on /q.
+func newQuotaService(t *testing.T) (*fakeFiler, *SeaweedFSService) {
+	t.Helper()
+	f, srv := newFakeFiler(t)
+	s := NewSeaweedFSService(srv.URL, WithQuotas(time.Hour))
+	ctx := context.Background()
+	if err := s.Mkdir(ctx, "/q/"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetQuota(ctx, "/q", 100); err != nil {
+		t.Fatal(err)
+	}
+	return f, s
+}
+
+func putBytes(s *SeaweedFSService, p string, n int) error {
+	return s.UploadWithOptions(context.Background(), UploadMethodPut, p, bytes.NewReader(make([]byte, n)), nil, nil, nil)
+}
+
+func TestQuotaReserveAndReject(t *testing.T) {
+	f, s := newQuotaService(t)
+
+	if err := putBytes(s, "/q/a", 60); err != nil {
+		t.Fatal(err)
+	}
+	err := putBytes(s, "/q/sub/b", 50)
+	var qe *QuotaError
+	if !errors.Is(err, ErrQuotaExceeded) || !errors.As(err, &qe) {
+		t.Fatalf("upload over quota: got %v, want *QuotaError", err)
+	}
+	if qe.Dir != "/q" || qe.Used != 60 || qe.Requested != 50 {
+		t.Fatalf("quota error: got %+v", qe)
+	}
+	if _, ok := f.file("/q/sub/b"); ok {
+		t.Fatal("rejected upload reached the filer")
+	}
+
+	// Directories without a quota are not limited.
+	if err := putBytes(s, "/other/big", 500); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestQuotaUnknownSize(t *testing.T) {
+	f, s := newQuotaService(t)
+	ctx := context.Background()
+
+	r := io.MultiReader(strings.NewReader(strings.Repeat("x", 60)), strings.NewReader(strings.Repeat("y", 60)))
+	err := s.UploadWithOptions(ctx, UploadMethodPut, "/q/stream", r, nil, nil, nil)
+	if !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("streamed upload over quota: got %v, want ErrQuotaExceeded", err)
+	}
+	if _, ok := f.file("/q/stream"); ok {
+		t.Fatal("aborted upload reached the filer")
+	}
+
+	u, err := s.GetQuotaUsage(ctx, "/q")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Used != 0 || u.Reserved != 0 {
+		t.Fatalf("usage after aborted upload: got %+v", u)
+	}
+}
+
+// countingTransport counts the upload requests it sends.
+type countingTransport struct {
+	mu      sync.Mutex
+	uploads int
+}
+
+func (c *countingTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	if r.Method == http.MethodPut || r.Method == http.MethodPost {
+		c.mu.Lock()
+		c.uploads++
+		c.mu.Unlock()
+	}
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+func TestQuotaLocalFile(t *testing.T) {
+	_, srv := newFakeFiler(t)
+	ct := &countingTransport{}
+	s := NewSeaweedFSServiceWithClient(srv.URL, &http.Client{Transport: ct}, WithQuotas(time.Hour))
+	ctx := context.Background()
+	if err := s.Mkdir(ctx, "/q/"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetQuota(ctx, "/q", 100); err != nil {
+		t.Fatal(err)
+	}
+
+	local := filepath.Join(t.TempDir(), "big")
+	if err := os.WriteFile(local, make([]byte, 150), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// The file's size is known, so the upload is rejected before any request is sent.
+	ct.uploads = 0
+	err := s.UploadLocalFile(ctx, UploadMethodPut, "/q/big", local, 1<<20, 0, nil, nil, nil)
+	if !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("upload over quota: got %v, want ErrQuotaExceeded", err)
+	}
+	if ct.uploads != 0 {
+		t.Fatalf("rejected upload sent %d requests", ct.uploads)
+	}
+
+	if err := os.WriteFile(local, make([]byte, 80), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.UploadLocalFile(ctx, UploadMethodPut, "/q/big", local, 1<<20, 0, nil, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	u, err := s.GetQuotaUsage(ctx, "/q")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Used != 80 || u.Reserved != 0 {
+		t.Fatalf("usage: got %+v", u)
+	}
+}
+
+func TestQuotaConcurrentReservations(t *testing.T) {
+	_, s := newQuotaService(t)
+
+	var wg sync.WaitGroup
+	errs := make([]error, 10)
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = putBytes(s, "/q/p"+string(rune('0'+i)), 10)
+		}()
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("upload %d: %v", i, err)
+		}
+	}
+	if err := putBytes(s, "/q/z", 1); !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("upload into a full quota: got %v, want ErrQuotaExceeded", err)
+	}
+}
+
+func TestQuotaOverwrite(t *testing.T) {
+	_, s := newQuotaService(t)
+
+	if err := putBytes(s, "/q/a", 60); err != nil {
+		t.Fatal(err)
+	}
+	// Overwriting only needs room for the growth.
+	if err := putBytes(s, "/q/a", 80); err != nil {
+		t.Fatal(err)
+	}
+	if err := putBytes(s, "/q/b", 30); !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("upload past the overwritten file: got %v, want ErrQuotaExceeded", err)
+	}
+	// Shrinking the file gives the difference back.
+	if err := putBytes(s, "/q/a", 40); err != nil {
+		t.Fatal(err)
+	}
+	if err := putBytes(s, "/q/b", 60); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestQuotaReconcile(t *testing.T) {
+	_, s := newQuotaService(t)
+	ctx := context.Background()
+
+	if err := putBytes(s, "/q/a", 60); err != nil {
+		t.Fatal(err)
+	}
+	if err := putBytes(s, "/q/b", 30); err != nil {
+		t.Fatal(err)
+	}
+	if err := putBytes(s, "/q/c", 50); !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("upload over quota: got %v, want ErrQuotaExceeded", err)
+	}
+
+	// Moving a file out of the quota frees its bytes.
+	if err := s.Mkdir(ctx, "/archive/"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Move(ctx, "/q/a", "/archive/a"); err != nil {
+		t.Fatal(err)
+	}
+	if err := putBytes(s, "/q/c", 50); err != nil {
+		t.Fatal(err)
+	}
+
+	// So does deleting one.
+	if err := s.Delete(ctx, "/q/c", nil); err != nil {
+		t.Fatal(err)
+	}
+	u, err := s.GetQuotaUsage(ctx, "/q")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Used != 30 || u.Reserved != 0 || u.Remaining() != 70 {
+		t.Fatalf("usage after move and delete: got %+v", u)
+	}
+
+	// Removing the quota lifts the limit.
+	if err := s.SetQuota(ctx, "/q", 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := putBytes(s, "/q/big", 500); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,105 @@
+// Package seaweedfs provides a Go client for interacting with SeaweedFS.
+// It includes upload/download bandwidth and request-rate limiting.
+// 提供 SeaweedFS 的 Go 客户端, 包括上传/下载带宽限制与请求速率限制.
+package seaweedfs
+
+import (
+	"context"
+
+	"github.com/GoFurry/seaweedfs-sdk-go/internal/ratelimit"
+)
+
+// WithUploadLimit limits upload bandwidth to bytesPerSec across the service; 0 disables the limit.
+// 限制整个服务的上传带宽 (字节/秒), 0 表示不限制.
+func WithUploadLimit(bytesPerSec int64) Option {
+	return func(s *SeaweedFSService) {
+		s.SetUploadLimit(bytesPerSec)
+	}
+}
+
+// WithDownloadLimit limits download bandwidth to bytesPerSec across the service; 0 disables the limit.
+// 限制整个服务的下载带宽 (字节/秒), 0 表示不限制.
+func WithDownloadLimit(bytesPerSec int64) Option {
+	return func(s *SeaweedFSService) {
+		s.SetDownloadLimit(bytesPerSec)
+	}
+}
+
+// WithRequestRate limits the number of HTTP requests per second across the service; 0 disables the limit.
+// 限制整个服务每秒的 HTTP 请求数, 0 表示不限制.
+func WithRequestRate(perSec float64) Option {
+	return func(s *SeaweedFSService) {
+		s.SetRequestRate(perSec)
+	}
+}
+
+// SetUploadLimit changes the upload bandwidth limit at runtime, affecting transfers in flight.
+// 在运行时修改上传带宽限制, 对进行中的传输同样生效.
+func (s *SeaweedFSService) SetUploadLimit(bytesPerSec int64) {
+	s.uploadLimit.SetRate(float64(bytesPerSec), 0)
+}
+
+// SetDownloadLimit changes the download bandwidth limit at runtime, affecting transfers in flight.
+// 在运行时修改下载带宽限制, 对进行中的传输同样生效.
+func (s *SeaweedFSService) SetDownloadLimit(bytesPerSec int64) {
+	s.downloadLimit.SetRate(float64(bytesPerSec), 0)
+}
+
+// SetRequestRate changes the request-rate limit at runtime. 在运行时修改请求速率限制.
+func (s *SeaweedFSService) SetRequestRate(perSec float64) {
+	s.requestLimit.SetRate(perSec, 0)
+}
+
+// Limits overrides the service limits for the calls made with a context from ContextWithLimits.
+// Zero fields keep the service limit; negative fields disable limiting for those calls.
+// 覆盖使用 ContextWithLimits 返回的 context 发起的调用的服务限制. 零值字段沿用服务限制, 负值表示不限制.
+type Limits struct {
+	UploadBytesPerSec   int64   // Upload bandwidth / 上传带宽 (字节/秒)
+	DownloadBytesPerSec int64   // Download bandwidth / 下载带宽 (字节/秒)
+	RequestsPerSec      float64 // Request rate / 请求速率 (次/秒)
+}
+
+type limitsKey struct{}
+
+// ctxLimiters are shared by every request made with the same context, e.g. all DownloadConcurrent workers.
+type ctxLimiters struct {
+	upload, download, request *ratelimit.Limiter
+}
+
+// ContextWithLimits returns a context whose calls use l instead of the service limits.
+// The limits are shared by all calls made with the returned context.
+// 返回使用 l 代替服务限制的 context, 使用该 context 的所有调用共享这些限制.
+func ContextWithLimits(ctx context.Context, l Limits) context.Context {
+	newLimiter := func(rate float64) *ratelimit.Limiter {
+		switch {
+		case rate > 0:
+			return ratelimit.New(rate, 0)
+		case rate < 0:
+			return ratelimit.New(0, 0)
+		}
+		return nil
+	}
+	return context.WithValue(ctx, limitsKey{}, &ctxLimiters{
+		upload:   newLimiter(float64(l.UploadBytesPerSec)),
+		download: newLimiter(float64(l.DownloadBytesPerSec)),
+		request:  newLimiter(l.RequestsPerSec),
+	})
+}
+
+// limiters returns the upload, download and request limiters in effect for ctx.
+// 返回 ctx 生效的上传、下载与请求限流器.
+func (s *SeaweedFSService) limiters(ctx context.Context) (upload, download, request *ratelimit.Limiter) {
+	upload, download, request = s.uploadLimit, s.downloadLimit, s.requestLimit
+	if cl, ok := ctx.Value(limitsKey{}).(*ctxLimiters); ok {
+		if cl.upload != nil {
+			upload = cl.upload
+		}
+		if cl.download != nil {
+			download = cl.download
+		}
+		if cl.request != nil {
+			request = cl.request
+		}
+	}
+	return upload, download, request
+}
@@ -0,0 +1,95 @@
+package seaweedfs
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"testing"
+)
+
+func TestReplicateCheckpoint(t *testing.T) {
+	_, srcSrv := newFakeFiler(t)
+	df, dstSrv := newFakeFiler(t)
+	src, dst := NewSeaweedFSService(srcSrv.URL), NewSeaweedFSService(dstSrv.URL)
+	ctx := context.Background()
+
+	for _, p := range []string{"/data/a", "/data/sub/b", "/data/c"} {
+		if err := putBytes(src, p, 3); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := src.SetTags(ctx, "/data/a", FileTags{"Owner": "ann"}); err != nil {
+		t.Fatal(err)
+	}
+	o := &ReplicateOptions{Checkpoint: filepath.Join(t.TempDir(), "cp.jsonl"), Incremental: true, Verify: true}
+
+	// The first run is interrupted by a failing upload.
+	df.hook = func(w http.ResponseWriter, r *http.Request) bool {
+		if r.Method == http.MethodPut && r.URL.Path == "/data/sub/b" {
+			w.WriteHeader(http.StatusForbidden)
+			return true
+		}
+		return false
+	}
+	report, err := Replicate(ctx, src, dst, "/data", o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Copied != 2 || report.Failed["/data/sub/b"] == nil {
+		t.Fatalf("first run: %+v", report)
+	}
+	stat, err := src.Stat(ctx, "/data/a", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tags, err := dst.GetTags(ctx, "/data/a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tags["Owner"] != "ann" || tags[mtimeTag] == "" {
+		t.Fatalf("replicated tags: %v", tags)
+	}
+	if ds, err := dst.Stat(ctx, "/data/a", true); err != nil || !modTime(ds).Equal(modTime(stat)) {
+		t.Fatalf("modification time not preserved: %v", err)
+	}
+
+	// The resumed run only copies what failed.
+	uploads := 0
+	df.hook = func(_ http.ResponseWriter, r *http.Request) bool {
+		if r.Method == http.MethodPut && r.URL.RawQuery != "tagging" {
+			uploads++
+		}
+		return false
+	}
+	report, err = Replicate(ctx, src, dst, "/data", o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Copied != 1 || report.Skipped != 2 || len(report.Failed) != 0 || uploads != 1 {
+		t.Fatalf("resumed run: %+v, %d uploads", report, uploads)
+	}
+	if got, ok := df.file("/data/sub/b"); !ok || len(got) != 3 {
+		t.Fatalf("resumed file: %q, %v", got, ok)
+	}
+
+	// An incremental run after the completed one copies only the changed file.
+	if err := putBytes(src, "/data/c", 5); err != nil {
+		t.Fatal(err)
+	}
+	uploads = 0
+	report, err = Replicate(ctx, src, dst, "/data", o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Copied != 1 || report.Skipped != 2 || uploads != 1 {
+		t.Fatalf("incremental run: %+v, %d uploads", report, uploads)
+	}
+	if got, _ := df.file("/data/c"); len(got) != 5 {
+		t.Fatalf("changed file: %d bytes", len(got))
+	}
+
+	// A checkpoint of another replication is refused.
+	if _, err := Replicate(ctx, src, dst, "/other", o); err == nil {
+		t.Fatal("foreign checkpoint accepted")
+	}
+}
@@ -6,7 +6,6 @@ package seaweedfs
 import (
 	"context"
 	"encoding/json"
-	"fmt"
 	"io"
 	"net/http"
 	"os"
@@ -22,6 +21,27 @@ import (
 // includeTags indicates whether to also fetch custom tags for the entry.
 // 获取文件或目录的元数据, includeTags 表示是否同时获取自定义标签.
 func (s *SeaweedFSService) Stat(ctx context.Context, p string, includeTags bool) (*SeaweedStat, error) {
+	raw, err := s.entry(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+	stat := raw.stat()
+
+	// Fetch tags separately because SeaweedFS exposes tags via HTTP headers.
+	if includeTags {
+		tags, err := s.GetTags(ctx, raw.FullPath)
+		if err != nil {
+			// Tag fetching errors are intentionally ignored to avoid breaking stat.
+			tags = nil
+		}
+		stat.Tags = tags
+	}
+
+	return stat, nil
+}
+
+// entry fetches the raw filer metadata of p. 获取 p 的原始 filer 元数据.
+func (s *SeaweedFSService) entry(ctx context.Context, p string) (*filerEntry, error) {
 	// SeaweedFS filer expects absolute paths.
 	if !path.IsAbs(p) {
 		p = "/" + p
@@ -34,7 +54,7 @@ func (s *SeaweedFSService) Stat(ctx context.Context, p string, includeTags bool)
 	}
 	req.Header.Set("Accept", "application/json")
 
-	resp, err := s.client.Do(req)
+	resp, err := s.do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -47,56 +67,71 @@ func (s *SeaweedFSService) Stat(ctx context.Context, p string, includeTags bool)
 	// Any other 4xx or 5xx response is treated as a hard failure.
 	if resp.StatusCode >= 400 {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("stat failed: %s %s", resp.Status, string(body))
+		return nil, &StatusError{Op: "stat", Code: resp.StatusCode, Status: resp.Status, Body: string(body)}
 	}
 
 	// Raw response structure mirrors SeaweedFS metadata JSON format.
-	var raw struct {
-		FullPath    string  `json:"FullPath"`
-		Mtime       string  `json:"Mtime"`
-		Crtime      string  `json:"Crtime"`
-		Mode        uint32  `json:"Mode"`
-		Mime        string  `json:"Mime"`
-		Replication string  `json:"Replication"`
-		Collection  string  `json:"Collection"`
-		TtlSec      int32   `json:"TtlSec"`
-		Md5         *string `json:"Md5"`
-		FileSize    int64   `json:"FileSize"`
-	}
-
+	var raw filerEntry
 	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
 		return nil, err
 	}
+	return &raw, nil
+}
+
+// filerEntry mirrors an entry in the filer's metadata and listing JSON. 对应 filer 元数据与列表 JSON 中的条目.
+type filerEntry struct {
+	FullPath      string            `json:"FullPath"`
+	Mtime         string            `json:"Mtime"`
+	Crtime        string            `json:"Crtime"`
+	Mode          uint32            `json:"Mode"`
+	Uid           uint32            `json:"Uid"`
+	Gid           uint32            `json:"Gid"`
+	UserName      string            `json:"UserName"`
+	GroupNames    []string          `json:"GroupNames"`
+	SymlinkTarget string            `json:"SymlinkTarget"`
+	Inode         uint64            `json:"Inode"`
+	Mime          string            `json:"Mime"`
+	Replication   string            `json:"Replication"`
+	Collection    string            `json:"Collection"`
+	TtlSec        int32             `json:"TtlSec"`
+	Md5           *string           `json:"Md5"`
+	FileSize      int64             `json:"FileSize"`
+	Extended      map[string][]byte `json:"Extended"`
+	Chunks        []filerChunk      `json:"chunks"`
+}
 
-	// Convert SeaweedFS raw metadata into SDK-friendly SeaweedStat.
+// stat converts the raw entry into an SDK-friendly SeaweedStat without tags.
+// 将原始条目转换为 SDK 友好的 SeaweedStat (不含标签).
+func (e *filerEntry) stat() *SeaweedStat {
+	fullPath := strings.TrimRight(e.FullPath, "/")
+	if fullPath == "" {
+		fullPath = "/"
+	}
 	stat := &SeaweedStat{
-		Path:  raw.FullPath,
-		Name:  path.Base(raw.FullPath),
-		IsDir: raw.Mode&uint32(os.ModeDir) != 0,
-		Size:  raw.FileSize,
-		Mime:  raw.Mime,
+		Path:  e.FullPath,
+		Name:  path.Base(fullPath),
+		IsDir: e.Mode&uint32(os.ModeDir) != 0,
+		Size:  e.FileSize,
+		Mime:  e.Mime,
 		// Md5 may be null for directories or certain files.
-		Md5: util.DerefString(raw.Md5),
+		Md5: util.DerefString(e.Md5),
 		// SeaweedFS uses RFC3339 time strings.
-		Mtime:       util.ParseSeaweedTime(raw.Mtime),
-		Crtime:      util.ParseSeaweedTime(raw.Crtime),
-		Mode:        raw.Mode,
-		Replication: raw.Replication,
-		Collection:  raw.Collection,
-		TtlSec:      raw.TtlSec,
-	}
-
-	// Fetch tags separately because SeaweedFS exposes tags via HTTP headers.
-	if includeTags {
-		tags, err := s.GetTags(ctx, raw.FullPath)
-		if err != nil {
-			// Tag fetching errors are intentionally ignored to avoid breaking stat.
-			tags = nil
-		}
-		stat.Tags = tags
-	}
-
-	return stat, nil
+		Mtime:         util.ParseSeaweedTime(e.Mtime),
+		Crtime:        util.ParseSeaweedTime(e.Crtime),
+		Mode:          e.Mode,
+		Uid:           e.Uid,
+		Gid:           e.Gid,
+		UserName:      e.UserName,
+		GroupNames:    e.GroupNames,
+		SymlinkTarget: e.SymlinkTarget,
+		Inode:         e.Inode,
+		Replication:   e.Replication,
+		Collection:    e.Collection,
+		TtlSec:        e.TtlSec,
+	}
+	// Attributes changed through Chmod, Chown, Chtimes and Symlink are kept in Extended.
+	applyPosixOverlay(stat, e.Extended)
+	return stat
 }
 
 // StatBatch retrieves metadata for multiple files or directories concurrently.
@@ -255,7 +290,7 @@ func (s *SeaweedFSService) SetTags(ctx context.Context, path string, tags FileTa
 		req.Header.Set("Seaweed-"+k, v)
 	}
 
-	resp, err := s.client.Do(req)
+	resp, err := s.do(req)
 	if err != nil {
 		return err
 	}
@@ -265,20 +300,38 @@ func (s *SeaweedFSService) SetTags(ctx context.Context, path string, tags FileTa
 		return nil
 	}
 	body, _ := io.ReadAll(resp.Body)
-	return fmt.Errorf("set tags failed: %s %s", resp.Status, body)
+	return &StatusError{Op: "set tags", Code: resp.StatusCode, Status: resp.Status, Body: string(body)}
 }
 
 // GetTags retrieves custom tags of a file or directory. 获取文件或目录的自定义标签.
 func (s *SeaweedFSService) GetTags(ctx context.Context, path string) (FileTags, error) {
-	path = util.NormalizePath(path)
-
 	// SeaweedFS exposes tags via response headers on HEAD requests.
-	req, err := http.NewRequestWithContext(ctx, http.MethodHead, s.FilerEndpoint+path, nil)
+	header, err := s.head(ctx, path, "get tags")
+	if err != nil {
+		return nil, err
+	}
+
+	tags := make(FileTags)
+	for k, vals := range header {
+		// Only headers with "Seaweed-" prefix are treated as tags.
+		if strings.HasPrefix(k, "Seaweed-") && len(vals) > 0 {
+			tags[strings.TrimPrefix(k, "Seaweed-")] = vals[0]
+		}
+	}
+	return tags, nil
+}
+
+// head issues a HEAD request for an entry and returns its response headers. op names the operation in errors.
+// 对条目发送 HEAD 请求并返回响应头, op 用于错误信息中的操作名称.
+func (s *SeaweedFSService) head(ctx context.Context, p string, op string) (http.Header, error) {
+	p = util.NormalizePath(p)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, s.FilerEndpoint+p, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := s.client.Do(req)
+	resp, err := s.do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -289,17 +342,9 @@ func (s *SeaweedFSService) GetTags(ctx context.Context, path string) (FileTags,
 	}
 	if resp.StatusCode >= 400 {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("get tags failed: %s %s", resp.Status, body)
+		return nil, &StatusError{Op: op, Code: resp.StatusCode, Status: resp.Status, Body: string(body)}
 	}
-
-	tags := make(FileTags)
-	for k, vals := range resp.Header {
-		// Only headers with "Seaweed-" prefix are treated as tags.
-		if strings.HasPrefix(k, "Seaweed-") && len(vals) > 0 {
-			tags[strings.TrimPrefix(k, "Seaweed-")] = vals[0]
-		}
-	}
-	return tags, nil
+	return resp.Header, nil
 }
 
 // DeleteTags deletes custom tags of a file or directory.
@@ -319,7 +364,7 @@ func (s *SeaweedFSService) DeleteTags(ctx context.Context, path string, keys ...
 		return err
 	}
 
-	resp, err := s.client.Do(req)
+	resp, err := s.do(req)
 	if err != nil {
 		return err
 	}
@@ -329,7 +374,7 @@ func (s *SeaweedFSService) DeleteTags(ctx context.Context, path string, keys ...
 		return nil
 	}
 	body, _ := io.ReadAll(resp.Body)
-	return fmt.Errorf("delete tags failed: %s %s", resp.Status, body)
+	return &StatusError{Op: "delete tags", Code: resp.StatusCode, Status: resp.Status, Body: string(body)}
 }
 
 // GetDirUsage recursively calculates storage usage of a directory.
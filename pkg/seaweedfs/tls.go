@@ -0,0 +1,214 @@
+// Package seaweedfs provides a Go client for interacting with SeaweedFS.
+// It includes TLS configuration such as custom CA bundles and mutual TLS client certificates.
+// 提供 SeaweedFS 的 Go 客户端, 包括 TLS 配置, 如自定义 CA 证书和双向 TLS 客户端证书.
+package seaweedfs
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// TLSOptions defines TLS settings shared by filer, master and volume connections.
+// 定义 filer、master 与 volume 连接共用的 TLS 配置.
+type TLSOptions struct {
+	CAFile     string // PEM CA bundle used to verify servers / 用于校验服务端的 PEM CA 证书
+	CertFile   string // PEM client certificate for mTLS / 双向 TLS 客户端证书
+	KeyFile    string // PEM client private key for mTLS / 双向 TLS 客户端私钥
+	ServerName string // Override of the expected server name / 覆盖期望的服务端名称
+	MinVersion uint16 // Minimum TLS version, e.g. tls.VersionTLS12 / 最低 TLS 版本
+}
+
+// NewTLSConfig builds a *tls.Config from TLSOptions.
+// The client certificate is reloaded automatically when its files change on disk.
+// 根据 TLSOptions 构建 *tls.Config, 客户端证书文件变更时会自动重新加载.
+func NewTLSConfig(o TLSOptions) (*tls.Config, error) {
+	cfg := &tls.Config{
+		ServerName: o.ServerName,
+		MinVersion: o.MinVersion,
+	}
+	if cfg.MinVersion == 0 {
+		cfg.MinVersion = tls.VersionTLS12
+	}
+
+	if o.CAFile != "" {
+		pool, err := loadCertPool(o.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.RootCAs = pool
+	}
+
+	if o.CertFile != "" || o.KeyFile != "" {
+		if o.CertFile == "" || o.KeyFile == "" {
+			return nil, errors.New("tls: both cert file and key file are required")
+		}
+		r, err := newCertReloader(o.CertFile, o.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.GetClientCertificate = r.getClientCertificate
+	}
+
+	return cfg, nil
+}
+
+// WithTLSConfig applies a TLS configuration to the service HTTP transport.
+// 将 TLS 配置应用到服务的 HTTP 传输层.
+func WithTLSConfig(cfg *tls.Config) Option {
+	return func(s *SeaweedFSService) {
+		if cfg != nil {
+			s.tlsConfig = cfg.Clone()
+		}
+	}
+}
+
+// WithCACertFile trusts the PEM CA bundle at path when verifying servers. 使用 path 处的 PEM CA 证书校验服务端.
+func WithCACertFile(path string) Option {
+	return func(s *SeaweedFSService) {
+		pool, err := loadCertPool(path)
+		if err != nil {
+			s.configErr = err
+			return
+		}
+		s.ensureTLSConfig().RootCAs = pool
+	}
+}
+
+// WithClientCertificate enables mutual TLS with a certificate that is hot-reloaded on file change.
+// 启用双向 TLS, 客户端证书文件变更时自动热加载.
+func WithClientCertificate(certFile, keyFile string) Option {
+	return func(s *SeaweedFSService) {
+		r, err := newCertReloader(certFile, keyFile)
+		if err != nil {
+			s.configErr = err
+			return
+		}
+		s.ensureTLSConfig().GetClientCertificate = r.getClientCertificate
+	}
+}
+
+// WithTLSServerName overrides the server name used for certificate verification. 覆盖证书校验使用的服务端名称.
+func WithTLSServerName(name string) Option {
+	return func(s *SeaweedFSService) {
+		if name != "" {
+			s.ensureTLSConfig().ServerName = name
+		}
+	}
+}
+
+// WithMinTLSVersion sets the minimum TLS version, e.g. tls.VersionTLS13. 设置最低 TLS 版本.
+func WithMinTLSVersion(v uint16) Option {
+	return func(s *SeaweedFSService) {
+		if v > 0 {
+			s.ensureTLSConfig().MinVersion = v
+		}
+	}
+}
+
+func (s *SeaweedFSService) ensureTLSConfig() *tls.Config {
+	if s.tlsConfig == nil {
+		s.tlsConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	return s.tlsConfig
+}
+
+// applyTLS installs the TLS configuration on a copy of the HTTP client,
+// so a caller-supplied client is never mutated.
+// 在 HTTP 客户端副本上安装 TLS 配置, 不修改调用方传入的客户端.
+func (s *SeaweedFSService) applyTLS() {
+	if s.tlsConfig == nil {
+		return
+	}
+
+	var t *http.Transport
+	switch base := s.client.Transport.(type) {
+	case nil:
+		t = http.DefaultTransport.(*http.Transport).Clone()
+	case *http.Transport:
+		t = base.Clone()
+	default:
+		s.configErr = fmt.Errorf("tls: unsupported transport type %T", base)
+		return
+	}
+	t.TLSClientConfig = s.tlsConfig
+
+	c := *s.client
+	c.Transport = t
+	s.client = &c
+}
+
+func loadCertPool(path string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("tls: no certificates found in %s", path)
+	}
+	return pool, nil
+}
+
+// certReloader reloads a client certificate whenever the cert or key file changes.
+// 当证书或私钥文件变化时重新加载客户端证书.
+type certReloader struct {
+	certFile, keyFile string
+
+	mu       sync.Mutex
+	cert     *tls.Certificate
+	certTime time.Time
+	keyTime  time.Time
+}
+
+func newCertReloader(certFile, keyFile string) (*certReloader, error) {
+	r := &certReloader{certFile: certFile, keyFile: keyFile}
+	if _, err := r.load(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// load returns the current certificate, reading the files again if their modification time changed.
+func (r *certReloader) load() (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	certInfo, err := os.Stat(r.certFile)
+	if err != nil {
+		return r.fallback(err)
+	}
+	keyInfo, err := os.Stat(r.keyFile)
+	if err != nil {
+		return r.fallback(err)
+	}
+	if r.cert != nil && certInfo.ModTime().Equal(r.certTime) && keyInfo.ModTime().Equal(r.keyTime) {
+		return r.cert, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		// A half-written rotation keeps serving the previous certificate.
+		return r.fallback(err)
+	}
+	r.cert = &cert
+	r.certTime = certInfo.ModTime()
+	r.keyTime = keyInfo.ModTime()
+	return r.cert, nil
+}
+
+func (r *certReloader) fallback(err error) (*tls.Certificate, error) {
+	if r.cert != nil {
+		return r.cert, nil
+	}
+	return nil, err
+}
+
+func (r *certReloader) getClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	return r.load()
+}
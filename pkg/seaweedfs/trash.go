@@ -0,0 +1,295 @@
+// Package seaweedfs provides a Go client for interacting with SeaweedFS.
+// It includes an opt-in soft-delete trash bin with restore and purge.
+// 提供 SeaweedFS 的 Go 客户端, 包括可选的软删除回收站及恢复与清理功能.
+package seaweedfs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/GoFurry/seaweedfs-sdk-go/internal/util"
+)
+
+// Trash metadata is stored as tags on the trashed entry. 回收站元数据以标签形式存储在被删除的条目上.
+const (
+	trashTagOrigin    = "Trash-Origin"
+	trashTagDeletedAt = "Trash-Deleted-At"
+
+	// trashTimeLayout names the per-delete batch directories; it sorts chronologically.
+	trashTimeLayout = "20060102T150405.000000000Z"
+)
+
+// ErrTrashDisabled is returned by trash operations when trash mode is not enabled.
+// 未启用回收站模式时, 回收站操作返回该错误.
+var ErrTrashDisabled = errors.New("trash is not enabled")
+
+// WithTrash enables trash mode: Delete and DeleteBatch move entries into dir/<timestamp>/ instead of
+// removing them. Use a directory per user (e.g. "/.trash/alice") to keep trash bins apart;
+// an empty dir selects "/.trash".
+// 启用回收站模式: Delete 与 DeleteBatch 会把条目移动到 dir/<时间戳>/ 下而不是删除.
+// 可为每个用户使用独立目录 (如 "/.trash/alice"), dir 为空时使用 "/.trash".
+func WithTrash(dir string) Option {
+	return func(s *SeaweedFSService) {
+		if dir == "" {
+			dir = "/.trash"
+		}
+		s.trashDir = util.NormalizePath(dir)
+	}
+}
+
+// ConflictPolicy decides what happens when the destination of a restore, copy or move already exists.
+// 决定恢复、复制或移动的目标已存在时的处理方式.
+type ConflictPolicy int
+
+const (
+	ConflictFail      ConflictPolicy = iota // Return an error / 返回错误
+	ConflictOverwrite                       // Replace the existing entry / 替换已存在的条目
+	ConflictRename                          // Write next to it under a new name / 以新名称写到旁边
+	ConflictSkip                            // Leave the existing entry alone (Restore treats it as ConflictFail) / 保留已存在的条目 (Restore 视为 ConflictFail)
+)
+
+// TrashEntry describes an entry in the trash. 描述回收站中的条目.
+type TrashEntry struct {
+	Path         string    // Current path inside the trash / 在回收站中的当前路径
+	OriginalPath string    // Path before deletion / 删除前的路径
+	DeletedAt    time.Time // Deletion time / 删除时间
+	IsDir        bool      // Whether it's a directory / 是否为目录
+	Size         int64     // File size in bytes / 文件大小 (字节)
+}
+
+// inTrash reports whether p is the trash directory or inside it. 判断 p 是否为回收站目录或位于其中.
+func (s *SeaweedFSService) inTrash(p string) bool {
+	p = util.NormalizePath(p)
+	return p == s.trashDir || strings.HasPrefix(p, strings.TrimSuffix(s.trashDir, "/")+"/")
+}
+
+// moveToTrash moves p into a new batch directory of the trash and tags it with its origin.
+// Like the filer, a non-recursive delete of a non-empty directory fails and a missing entry is not an error.
+// 将 p 移动到回收站的新批次目录并记录其原始位置. 与 filer 一致, 非递归删除非空目录会失败, 条目不存在不视为错误.
+func (s *SeaweedFSService) moveToTrash(ctx context.Context, p string, extra map[string]string) error {
+	p = util.NormalizePath(p)
+	if strings.HasPrefix(s.trashDir, strings.TrimSuffix(p, "/")+"/") {
+		return fmt.Errorf("delete %s: cannot move an ancestor of the trash into the trash", p)
+	}
+
+	stat, err := s.Stat(ctx, p, false)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if stat.IsDir && extra["recursive"] != "true" {
+		page, err := s.ListPaged(ctx, p, "", 1, "", "", nil)
+		if err != nil {
+			return err
+		}
+		if len(page.Entries) > 0 {
+			return fmt.Errorf("delete %s: directory is not empty, set recursive=true", p)
+		}
+	}
+
+	now := time.Now().UTC()
+	batch := JoinPath(s.trashDir, now.Format(trashTimeLayout))
+	if err := s.Mkdir(ctx, batch); err != nil {
+		return err
+	}
+	dst := JoinPath(batch, path.Base(p))
+	if err := s.Move(ctx, p, dst); err != nil {
+		return err
+	}
+	err = s.SetTags(ctx, dst, FileTags{
+		trashTagOrigin:    p,
+		trashTagDeletedAt: now.Format(time.RFC3339Nano),
+	})
+	if err != nil {
+		// Without its origin tag the entry could not be restored; put it back so the delete fails cleanly.
+		if mvErr := s.Move(context.WithoutCancel(ctx), dst, p); mvErr != nil {
+			return fmt.Errorf("delete %s: tag trash entry: %w (moving it back failed: %v)", p, err, mvErr)
+		}
+		_ = s.deleteEntry(context.WithoutCancel(ctx), batch, nil)
+		return err
+	}
+	return nil
+}
+
+// ListTrash returns the entries in the trash, most recently deleted first.
+// 返回回收站中的条目, 最近删除的在前.
+func (s *SeaweedFSService) ListTrash(ctx context.Context) ([]TrashEntry, error) {
+	if s.trashDir == "" {
+		return nil, ErrTrashDisabled
+	}
+
+	batches, err := s.List(ctx, s.trashDir, "", "", nil)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var out []TrashEntry
+	for _, b := range batches {
+		if !b.IsDir {
+			continue
+		}
+		dir := JoinPath(s.trashDir, b.Name)
+		entries, err := s.List(ctx, dir, "", "", nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			te, err := s.trashEntry(ctx, JoinPath(dir, e.Name))
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, te)
+		}
+	}
+
+	sort.SliceStable(out, func(i, j int) bool { return out[i].DeletedAt.After(out[j].DeletedAt) })
+	return out, nil
+}
+
+// trashEntry reads the trash metadata of p, falling back to its batch directory name for the deletion time.
+// 读取 p 的回收站元数据, 缺少删除时间时回退到批次目录名.
+func (s *SeaweedFSService) trashEntry(ctx context.Context, p string) (TrashEntry, error) {
+	stat, err := s.Stat(ctx, p, true)
+	if err != nil {
+		return TrashEntry{}, err
+	}
+	te := TrashEntry{
+		Path:         p,
+		OriginalPath: stat.Tags[trashTagOrigin],
+		IsDir:        stat.IsDir,
+		Size:         stat.Size,
+	}
+	te.DeletedAt, err = time.Parse(time.RFC3339Nano, stat.Tags[trashTagDeletedAt])
+	if err != nil {
+		te.DeletedAt, _ = time.Parse(trashTimeLayout, path.Base(path.Dir(p)))
+	}
+	return te, nil
+}
+
+// Restore moves a trashed entry (a Path from ListTrash) back to its original location and returns
+// the path it was restored to. conflict decides what happens when that location is taken;
+// with ConflictOverwrite the existing entry is itself moved to the trash.
+// 将回收站中的条目 (ListTrash 返回的 Path) 移回原位置并返回恢复后的路径. conflict 决定原位置已被占用时的处理方式,
+// ConflictOverwrite 会把已存在的条目移入回收站.
+func (s *SeaweedFSService) Restore(ctx context.Context, trashPath string, conflict ConflictPolicy) (string, error) {
+	if s.trashDir == "" {
+		return "", ErrTrashDisabled
+	}
+	trashPath = util.NormalizePath(trashPath)
+	if !s.inTrash(trashPath) || trashPath == s.trashDir {
+		return "", fmt.Errorf("restore %s: not a trash entry", trashPath)
+	}
+
+	te, err := s.trashEntry(ctx, trashPath)
+	if err != nil {
+		return "", err
+	}
+	if te.OriginalPath == "" {
+		return "", fmt.Errorf("restore %s: original path unknown", trashPath)
+	}
+
+	dst, err := s.resolveConflict(ctx, te.OriginalPath, conflict)
+	if err != nil {
+		return "", err
+	}
+
+	// The original parent may have been deleted in the meantime.
+	_ = s.Mkdir(ctx, path.Dir(dst))
+	if err := s.Move(ctx, trashPath, dst); err != nil {
+		return "", err
+	}
+	if err := s.DeleteTags(ctx, dst, trashTagOrigin, trashTagDeletedAt); err != nil {
+		return dst, err
+	}
+
+	// Drop the batch directory once it is empty.
+	batch := path.Dir(trashPath)
+	if page, err := s.ListPaged(ctx, batch, "", 1, "", "", nil); err == nil && len(page.Entries) == 0 {
+		_ = s.deleteEntry(ctx, batch, nil)
+	}
+	return dst, nil
+}
+
+// resolveConflict returns the path to restore to under the given conflict policy.
+// 根据冲突策略返回恢复的目标路径.
+func (s *SeaweedFSService) resolveConflict(ctx context.Context, dst string, conflict ConflictPolicy) (string, error) {
+	exists, err := s.Exists(ctx, dst)
+	if err != nil || !exists {
+		return dst, err
+	}
+
+	switch conflict {
+	case ConflictOverwrite:
+		return dst, s.Delete(ctx, dst, map[string]string{"recursive": "true"})
+	case ConflictRename:
+		return s.freeName(ctx, dst, "restored")
+	default:
+		return "", fmt.Errorf("%s already exists: %w", dst, os.ErrExist)
+	}
+}
+
+// freeName returns the first non-existing "name (label N).ext" next to dst.
+// 返回 dst 旁边第一个不存在的 "name (label N).ext".
+func (s *SeaweedFSService) freeName(ctx context.Context, dst, label string) (string, error) {
+	ext := path.Ext(dst)
+	stem := strings.TrimSuffix(dst, ext)
+	for i := 1; ; i++ {
+		candidate := stem + " (" + label + " " + strconv.Itoa(i) + ")" + ext
+		exists, err := s.Exists(ctx, candidate)
+		if err != nil {
+			return "", err
+		}
+		if !exists {
+			return candidate, nil
+		}
+	}
+}
+
+// PurgeTrash permanently deletes trash batches older than olderThan and returns the purged entry paths.
+// 永久删除早于 olderThan 的回收站批次, 返回被清理的条目路径.
+func (s *SeaweedFSService) PurgeTrash(ctx context.Context, olderThan time.Duration) ([]string, error) {
+	if s.trashDir == "" {
+		return nil, ErrTrashDisabled
+	}
+
+	batches, err := s.List(ctx, s.trashDir, "", "", nil)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	cutoff := time.Now().Add(-olderThan)
+	var purged []string
+	for _, b := range batches {
+		t, err := time.Parse(trashTimeLayout, b.Name)
+		if !b.IsDir || err != nil || t.After(cutoff) {
+			continue
+		}
+		dir := JoinPath(s.trashDir, b.Name)
+		entries, err := s.List(ctx, dir, "", "", nil)
+		if err != nil {
+			return purged, err
+		}
+		if err := s.deleteEntry(ctx, dir, map[string]string{"recursive": "true"}); err != nil {
+			return purged, err
+		}
+		for _, e := range entries {
+			purged = append(purged, JoinPath(dir, e.Name))
+		}
+	}
+	return purged, nil
+}
@@ -0,0 +1,167 @@
+package seaweedfs
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path"
+	"testing"
+	"time"
+)
+
+func TestTrashDeleteAndRestore(t *testing.T) {
+	f, srv := newFakeFiler(t)
+	s := NewSeaweedFSService(srv.URL, WithTrash(""))
+	ctx := context.Background()
+
+	if err := putBytes(s, "/docs/a.txt", 3); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Delete(ctx, "/docs/a.txt", nil); err != nil {
+		t.Fatal(err)
+	}
+	if exists(f, "/docs/a.txt") {
+		t.Fatal("deleted file still in place")
+	}
+
+	entries, err := s.ListTrash(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].OriginalPath != "/docs/a.txt" || entries[0].Size != 3 {
+		t.Fatalf("trash: got %+v", entries)
+	}
+	if time.Since(entries[0].DeletedAt) > time.Minute {
+		t.Fatalf("deleted at %v", entries[0].DeletedAt)
+	}
+
+	// The original location is taken again before the restore.
+	if err := putBytes(s, "/docs/a.txt", 1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Restore(ctx, entries[0].Path, ConflictFail); !errors.Is(err, os.ErrExist) {
+		t.Fatalf("restore over existing: got %v, want os.ErrExist", err)
+	}
+	dst, err := s.Restore(ctx, entries[0].Path, ConflictRename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dst != "/docs/a (restored 1).txt" {
+		t.Fatalf("restored to %s", dst)
+	}
+	if got, ok := f.file(dst); !ok || len(got) != 3 {
+		t.Fatalf("restored file: got %q, %v", got, ok)
+	}
+	tags, err := s.GetTags(ctx, dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := tags[trashTagOrigin]; ok {
+		t.Fatal("trash tags kept after restore")
+	}
+	if exists(f, path.Dir(entries[0].Path)) {
+		t.Fatal("empty batch directory kept")
+	}
+}
+
+func TestTrashRejectsNonEmptyDir(t *testing.T) {
+	f, srv := newFakeFiler(t)
+	s := NewSeaweedFSService(srv.URL, WithTrash("/.trash/u"))
+	ctx := context.Background()
+
+	if err := putBytes(s, "/d/x", 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Delete(ctx, "/d", nil); err == nil {
+		t.Fatal("non-recursive delete of a non-empty directory accepted")
+	}
+	if err := s.Delete(ctx, "/d", map[string]string{"recursive": "true"}); err != nil {
+		t.Fatal(err)
+	}
+	if exists(f, "/d/x") {
+		t.Fatal("directory still in place")
+	}
+	if err := s.Delete(ctx, "/", map[string]string{"recursive": "true"}); err == nil {
+		t.Fatal("moved an ancestor of the trash into the trash")
+	}
+}
+
+func TestTrashPurge(t *testing.T) {
+	f, srv := newFakeFiler(t)
+	s := NewSeaweedFSService(srv.URL, WithTrash(""))
+	ctx := context.Background()
+
+	for _, p := range []string{"/old", "/new"} {
+		if err := putBytes(s, p, 1); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.Delete(ctx, p, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	entries, err := s.ListTrash(ctx)
+	if err != nil || len(entries) != 2 {
+		t.Fatalf("trash: got %+v, %v", entries, err)
+	}
+
+	// Age the batch holding /old by renaming it to an earlier timestamp.
+	var oldPath string
+	for _, e := range entries {
+		if e.OriginalPath == "/old" {
+			oldBatch := JoinPath("/.trash", time.Now().UTC().Add(-2*time.Hour).Format(trashTimeLayout))
+			if err := s.Move(ctx, path.Dir(e.Path), oldBatch); err != nil {
+				t.Fatal(err)
+			}
+			oldPath = JoinPath(oldBatch, "old")
+		}
+	}
+
+	purged, err := s.PurgeTrash(ctx, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(purged) != 1 || purged[0] != oldPath {
+		t.Fatalf("purged %q, want [%s]", purged, oldPath)
+	}
+	if exists(f, oldPath) {
+		t.Fatal("purged entry still stored")
+	}
+	if entries, err := s.ListTrash(ctx); err != nil || len(entries) != 1 || entries[0].OriginalPath != "/new" {
+		t.Fatalf("trash after purge: got %+v, %v", entries, err)
+	}
+}
+
+func TestTrashRollsBackWhenTaggingFails(t *testing.T) {
+	f, srv := newFakeFiler(t)
+	s := NewSeaweedFSService(srv.URL, WithTrash(""))
+	ctx := context.Background()
+
+	if err := putBytes(s, "/a", 2); err != nil {
+		t.Fatal(err)
+	}
+	f.hook = func(w http.ResponseWriter, r *http.Request) bool {
+		if _, ok := r.URL.Query()["tagging"]; ok && r.Method == http.MethodPut {
+			w.WriteHeader(http.StatusInternalServerError)
+			return true
+		}
+		return false
+	}
+	if err := s.Delete(ctx, "/a", nil); err == nil {
+		t.Fatal("tagging failure ignored")
+	}
+	if !exists(f, "/a") {
+		t.Fatal("entry left untagged in the trash")
+	}
+	f.hook = nil
+	if entries, err := s.ListTrash(ctx); err != nil || len(entries) != 0 {
+		t.Fatalf("trash: got %+v, %v", entries, err)
+	}
+}
+
+func TestTrashDisabled(t *testing.T) {
+	s := NewSeaweedFSService("http://127.0.0.1:1")
+	if _, err := s.ListTrash(context.Background()); !errors.Is(err, ErrTrashDisabled) {
+		t.Fatalf("got %v, want ErrTrashDisabled", err)
+	}
+}
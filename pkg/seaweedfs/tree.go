@@ -0,0 +1,331 @@
+// Package seaweedfs provides a Go client for interacting with SeaweedFS.
+// It includes recursive copy and move of directory trees with progress, conflict policies and a resume journal.
+// 提供 SeaweedFS 的 Go 客户端, 包括目录树的递归复制与移动, 支持进度回报、冲突策略与断点续传日志.
+package seaweedfs
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/GoFurry/seaweedfs-sdk-go/internal/util"
+)
+
+// TreeOptions configures CopyTree and MoveTree. 配置 CopyTree 与 MoveTree.
+type TreeOptions struct {
+	Conflict    ConflictPolicy   // What to do when a destination file exists / 目标文件已存在时的处理方式
+	Concurrency int              // Files transferred in parallel (default 8) / 并行传输的文件数 (默认 8)
+	Journal     string           // Local journal file for resuming interrupted runs / 用于中断后续传的本地日志文件
+	Progress    TreeProgressFunc // Called after every file / 每个文件完成后调用
+}
+
+// TreeProgress reports one finished file together with aggregate progress.
+// 报告一个已完成的文件及整体进度.
+type TreeProgress struct {
+	Src        string // Source path / 源路径
+	Dst        string // Destination path (after renaming) / 目标路径 (重命名后)
+	Skipped    bool   // Skipped because of ConflictSkip or the journal / 因 ConflictSkip 或日志而跳过
+	Err        error  // Error for this file / 该文件的错误
+	FilesDone  int    // Files finished so far / 已完成的文件数
+	FilesTotal int    // Files in the tree / 目录树中的文件总数
+	BytesDone  int64  // Bytes finished so far / 已完成的字节数
+	BytesTotal int64  // Bytes in the tree / 目录树中的总字节数
+}
+
+// TreeProgressFunc receives per-file and aggregate progress. It may be called concurrently.
+// 接收单文件与整体进度, 可能被并发调用.
+type TreeProgressFunc func(TreeProgress)
+
+// TreeResult summarises a CopyTree or MoveTree run. CopyTree 或 MoveTree 的执行结果汇总.
+type TreeResult struct {
+	Dirs    int              // Directories created / 创建的目录数
+	Files   int              // Files transferred / 传输的文件数
+	Skipped int              // Files skipped (conflict or journal) / 跳过的文件数 (冲突或日志)
+	Bytes   int64            // Bytes transferred / 传输的字节数
+	Failed  map[string]error // Errors by source path / 按源路径记录的错误
+}
+
+// treeEntry is a file or directory found while walking the source. 遍历源目录时发现的文件或目录.
+type treeEntry struct {
+	rel   string
+	isDir bool
+	size  int64
+}
+
+// CopyTree recursively copies the directory src to dst: directories are recreated with Mkdir and files
+// are copied concurrently. Per-file errors are collected in the result; ConflictFail aborts the run.
+// 递归地将目录 src 复制到 dst: 用 Mkdir 重建目录并并发复制文件. 单个文件的错误收集在结果中, ConflictFail 会终止执行.
+func (s *SeaweedFSService) CopyTree(ctx context.Context, src, dst string, o *TreeOptions) (TreeResult, error) {
+	return s.transferTree(ctx, "copy", src, dst, o)
+}
+
+// MoveTree recursively moves the directory src to dst file by file, then removes the emptied source
+// directories. Unlike a single Move it applies the conflict policy and can resume from a journal.
+// 逐个文件地将目录 src 递归移动到 dst, 然后删除已清空的源目录. 与单次 Move 不同, 它会应用冲突策略并可通过日志续传.
+func (s *SeaweedFSService) MoveTree(ctx context.Context, src, dst string, o *TreeOptions) (TreeResult, error) {
+	return s.transferTree(ctx, "move", src, dst, o)
+}
+
+func (s *SeaweedFSService) transferTree(ctx context.Context, op, src, dst string, o *TreeOptions) (TreeResult, error) {
+	result := TreeResult{Failed: make(map[string]error)}
+	if o == nil {
+		o = &TreeOptions{}
+	}
+	concurrency := o.Concurrency
+	if concurrency <= 0 {
+		concurrency = 8
+	}
+
+	src, dst = util.NormalizePath(src), util.NormalizePath(dst)
+	if dst == src || strings.HasPrefix(dst, strings.TrimSuffix(src, "/")+"/") {
+		return result, fmt.Errorf("%s tree: destination %s is inside source %s", op, dst, src)
+	}
+
+	entries, err := s.scanTree(ctx, src)
+	if err != nil {
+		return result, err
+	}
+
+	journal, done, err := openTreeJournal(o.Journal, op, src, dst)
+	if err != nil {
+		return result, err
+	}
+	defer journal.Close()
+
+	// Directories first, parents before children.
+	var files []treeEntry
+	var filesTotal int
+	var bytesTotal int64
+	if err := s.Mkdir(ctx, dst); err != nil {
+		return result, err
+	}
+	for _, e := range entries {
+		if !e.isDir {
+			files = append(files, e)
+			filesTotal++
+			bytesTotal += e.size
+			continue
+		}
+		if err := s.Mkdir(ctx, JoinPath(dst, e.rel)); err != nil {
+			return result, err
+		}
+		result.Dirs++
+	}
+
+	var mu sync.Mutex
+	var filesDone int
+	var bytesDone int64
+	report := func(e treeEntry, to string, skipped bool, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		from := JoinPath(src, e.rel)
+		filesDone++
+		bytesDone += e.size
+		switch {
+		case err != nil:
+			result.Failed[from] = err
+		case skipped:
+			result.Skipped++
+		default:
+			result.Files++
+			result.Bytes += e.size
+		}
+		if err == nil {
+			journal.record(e.rel)
+		}
+		if o.Progress != nil {
+			o.Progress(TreeProgress{
+				Src: from, Dst: to, Skipped: skipped, Err: err,
+				FilesDone: filesDone, FilesTotal: filesTotal,
+				BytesDone: bytesDone, BytesTotal: bytesTotal,
+			})
+		}
+	}
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(concurrency)
+	for _, e := range files {
+		if gctx.Err() != nil {
+			break
+		}
+		if done[e.rel] {
+			report(e, JoinPath(dst, e.rel), true, nil)
+			continue
+		}
+		g.Go(func() error {
+			from, to := JoinPath(src, e.rel), JoinPath(dst, e.rel)
+
+			exists, err := s.Exists(gctx, to)
+			if err == nil && exists {
+				switch o.Conflict {
+				case ConflictSkip:
+					report(e, to, true, nil)
+					return nil
+				case ConflictRename:
+					to, err = s.freeName(gctx, to, "copy")
+				case ConflictOverwrite:
+					err = s.Delete(gctx, to, nil)
+				default:
+					err = fmt.Errorf("%s tree: %s already exists: %w", op, to, os.ErrExist)
+					report(e, to, false, err)
+					return err
+				}
+			}
+			if err == nil {
+				if op == "move" {
+					err = s.Move(gctx, from, to)
+				} else {
+					err = s.Copy(gctx, from, to)
+				}
+			}
+			report(e, to, false, err)
+			// Keep going on per-file errors unless the run itself was cancelled.
+			return gctx.Err()
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return result, err
+	}
+	if len(result.Failed) > 0 {
+		return result, nil
+	}
+
+	if op == "move" {
+		if err := s.removeEmptyDirs(ctx, src, entries); err != nil {
+			return result, err
+		}
+	}
+	return result, journal.remove()
+}
+
+// removeEmptyDirs deletes the source directories of a MoveTree, children first. Directories still
+// holding files (e.g. skipped on conflict) are left in place.
+// 由子到父删除 MoveTree 的源目录, 仍包含文件的目录 (如因冲突被跳过) 会被保留.
+func (s *SeaweedFSService) removeEmptyDirs(ctx context.Context, src string, entries []treeEntry) error {
+	dirs := []string{src}
+	for _, e := range entries {
+		if e.isDir {
+			dirs = append(dirs, JoinPath(src, e.rel))
+		}
+	}
+	for i := len(dirs) - 1; i >= 0; i-- {
+		page, err := s.ListPaged(ctx, dirs[i], "", 1, "", "", nil)
+		if err != nil {
+			return err
+		}
+		if len(page.Entries) > 0 {
+			continue
+		}
+		if err := s.deleteEntry(ctx, dirs[i], nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scanTree lists every entry below src, parents before children. 列出 src 下所有条目, 父目录在子条目之前.
+func (s *SeaweedFSService) scanTree(ctx context.Context, src string) ([]treeEntry, error) {
+	var entries []treeEntry
+	prefix := strings.TrimSuffix(src, "/") + "/"
+	err := s.Walk(ctx, src, func(p string, e SeaweedEntry) error {
+		entries = append(entries, treeEntry{rel: strings.TrimPrefix(p, prefix), isDir: e.IsDir, size: e.Size})
+		return nil
+	})
+	return entries, err
+}
+
+// treeJournal appends the relative path of every finished file to a local file so an interrupted
+// run can be resumed. A nil journal records nothing.
+// 将每个已完成文件的相对路径追加到本地文件, 以便中断后续传. nil 日志不记录任何内容.
+type treeJournal struct {
+	f    *os.File
+	path string
+}
+
+// treeJournalHeader identifies the run a journal belongs to. 标识日志所属的运行.
+type treeJournalHeader struct {
+	Op  string `json:"op"`
+	Src string `json:"src"`
+	Dst string `json:"dst"`
+}
+
+// openTreeJournal opens (or creates) the journal at p and returns the files it records as done.
+// A missing or empty file starts a new journal.
+// 打开 (或创建) 位于 p 的日志, 并返回其中记录为已完成的文件. 文件不存在或为空时开始新的日志.
+func openTreeJournal(p, op, src, dst string) (*treeJournal, map[string]bool, error) {
+	done := make(map[string]bool)
+	if p == "" {
+		return nil, done, nil
+	}
+	want := treeJournalHeader{Op: op, Src: src, Dst: dst}
+
+	if f, err := os.Open(p); err == nil {
+		sc := bufio.NewScanner(f)
+		var got treeJournalHeader
+		// An empty file, e.g. one created ahead of time or left by a crash before the header was
+		// written, is treated as a new journal.
+		started := sc.Scan()
+		if started {
+			_ = json.Unmarshal(sc.Bytes(), &got)
+		}
+		for sc.Scan() {
+			done[sc.Text()] = true
+		}
+		f.Close()
+		if err := sc.Err(); err != nil {
+			return nil, nil, err
+		}
+		if started && got != want {
+			return nil, nil, fmt.Errorf("journal %s belongs to %s %s -> %s", p, got.Op, got.Src, got.Dst)
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, nil, err
+	}
+
+	f, err := os.OpenFile(p, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(done) == 0 {
+		if st, err := f.Stat(); err == nil && st.Size() == 0 {
+			b, _ := json.Marshal(want)
+			if _, err := f.Write(append(b, '\n')); err != nil {
+				f.Close()
+				return nil, nil, err
+			}
+		}
+	}
+	return &treeJournal{f: f, path: p}, done, nil
+}
+
+// record marks rel as done. Write errors only cost a re-transfer on resume, so they are ignored.
+func (j *treeJournal) record(rel string) {
+	if j != nil {
+		_, _ = j.f.WriteString(rel + "\n")
+	}
+}
+
+// Close closes the journal file. 关闭日志文件.
+func (j *treeJournal) Close() error {
+	if j == nil {
+		return nil
+	}
+	return j.f.Close()
+}
+
+// remove deletes the journal after a complete run. 完整执行后删除日志.
+func (j *treeJournal) remove() error {
+	if j == nil {
+		return nil
+	}
+	j.f.Close()
+	return os.Remove(j.path)
+}
@@ -0,0 +1,102 @@
+package seaweedfs
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCopyTreeResumesFromJournal(t *testing.T) {
+	f, srv := newFakeFiler(t)
+	s := NewSeaweedFSService(srv.URL)
+	ctx := context.Background()
+
+	for _, p := range []string{"/src/a", "/src/sub/b", "/src/sub/c"} {
+		if err := putBytes(s, p, 2); err != nil {
+			t.Fatal(err)
+		}
+	}
+	journal := filepath.Join(t.TempDir(), "copy.journal")
+
+	f.hook = func(w http.ResponseWriter, r *http.Request) bool {
+		if r.URL.Query().Get("cp.from") == "/src/sub/b" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return true
+		}
+		return false
+	}
+	res, err := s.CopyTree(ctx, "/src", "/dst", &TreeOptions{Journal: journal, Concurrency: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Files != 2 || res.Failed["/src/sub/b"] == nil {
+		t.Fatalf("first run: %+v", res)
+	}
+	if _, err := os.Stat(journal); err != nil {
+		t.Fatalf("journal removed after a failed run: %v", err)
+	}
+
+	// Another run cannot reuse the journal.
+	if _, err := s.CopyTree(ctx, "/src", "/other", &TreeOptions{Journal: journal}); err == nil {
+		t.Fatal("journal of a different run accepted")
+	}
+
+	copies := 0
+	f.hook = func(_ http.ResponseWriter, r *http.Request) bool {
+		if r.URL.Query().Get("cp.from") != "" {
+			copies++
+		}
+		return false
+	}
+	res, err = s.CopyTree(ctx, "/src", "/dst", &TreeOptions{Journal: journal, Concurrency: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Files != 1 || res.Skipped != 2 || len(res.Failed) != 0 || copies != 1 {
+		t.Fatalf("resumed run: %+v, %d copies", res, copies)
+	}
+	if !exists(f, "/dst/sub/b") {
+		t.Fatal("failed file not copied on resume")
+	}
+	if _, err := os.Stat(journal); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("journal kept after a complete run: %v", err)
+	}
+}
+
+func TestMoveTreeConflicts(t *testing.T) {
+	f, srv := newFakeFiler(t)
+	s := NewSeaweedFSService(srv.URL)
+	ctx := context.Background()
+
+	for _, p := range []string{"/src/a.txt", "/src/sub/b", "/dst/a.txt"} {
+		if err := putBytes(s, p, 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := s.MoveTree(ctx, "/src", "/src/inner", nil); err == nil {
+		t.Fatal("move into the source accepted")
+	}
+	if _, err := s.MoveTree(ctx, "/src", "/dst", &TreeOptions{Concurrency: 1}); !errors.Is(err, os.ErrExist) {
+		t.Fatalf("ConflictFail: got %v, want os.ErrExist", err)
+	}
+
+	res, err := s.MoveTree(ctx, "/src", "/dst", &TreeOptions{Conflict: ConflictRename})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Files != 2 || len(res.Failed) != 0 {
+		t.Fatalf("rename: %+v", res)
+	}
+	if !exists(f, "/dst/a (copy 1).txt") || !exists(f, "/dst/sub/b") {
+		t.Fatal("renamed or nested file missing")
+	}
+	f.mu.Lock()
+	_, ok := f.m["/src"]
+	f.mu.Unlock()
+	if ok {
+		t.Fatal("emptied source directory kept")
+	}
+}
@@ -3,7 +3,11 @@
 // 提供 SeaweedFS 的 Go 客户端, 并定义了 SDK 各操作使用的通用类型.
 package seaweedfs
 
-import "time"
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
 
 // FileTags represents custom tags for a file or directory.
 // 表示文件或目录的自定义标签, key-value 形式.
@@ -20,11 +24,19 @@ type SeaweedStat struct {
 	Md5         string    `json:"md5,omitempty"`         // Optional MD5 checksum / 可选 MD5 校验值
 	Mtime       time.Time `json:"mtime"`                 // Last modification time / 最后修改时间
 	Crtime      time.Time `json:"crtime"`                // Creation time / 创建时间
+	Atime       time.Time `json:"atime"`                 // Access time set by Chtimes, otherwise Mtime / 由 Chtimes 设置的访问时间, 否则同 Mtime
 	Mode        uint32    `json:"mode"`                  // File mode / 文件模式
+	Uid         uint32    `json:"uid"`                   // Owner user ID / 所有者用户 ID
+	Gid         uint32    `json:"gid"`                   // Owner group ID / 所有者组 ID
+	UserName    string    `json:"userName,omitempty"`    // Owner user name / 所有者用户名
+	GroupNames  []string  `json:"groupNames,omitempty"`  // Owner group names / 所有者组名
+	Inode       uint64    `json:"inode,omitempty"`       // Inode number, if assigned / inode 编号 (如已分配)
 	Replication string    `json:"replication,omitempty"` // Optional replication info / 可选副本信息
 	Collection  string    `json:"collection,omitempty"`  // Optional collection / 可选集合
 	TtlSec      int32     `json:"ttlSec,omitempty"`      // Optional TTL in seconds / 可选生存时间 (秒)
 	Tags        FileTags  `json:"tags"`                  // Custom tags / 自定义标签
+
+	SymlinkTarget string `json:"symlinkTarget,omitempty"` // Target of a symbolic link / 符号链接的目标
 }
 
 // SeaweedEntry represents a file or directory entry when listing directories.
@@ -45,6 +57,22 @@ type ListPagedResult struct {
 	HasMore bool           // Whether there are more pages / 是否还有更多分页
 }
 
+// SeaweedEntryDetail is a fully parsed listing entry returned by ListDetail and ListPagedDetail.
+// Tags are filled from the listing itself.
+// ListDetail 与 ListPagedDetail 返回的完整解析的列表条目, Tags 直接取自列表结果.
+type SeaweedEntryDetail struct {
+	SeaweedStat
+	Chunks   int               `json:"chunks"`             // Number of stored chunks, 0 for inline content / 存储的分片数, 内联内容为 0
+	Extended map[string][]byte `json:"extended,omitempty"` // Raw extended attributes as stored by the filer / filer 存储的原始扩展属性
+}
+
+// ListPagedDetailResult represents a single page of ListPagedDetail. 表示 ListPagedDetail 的一页结果.
+type ListPagedDetailResult struct {
+	Entries []SeaweedEntryDetail // Entries in this page / 当前页的条目
+	Last    string               // Name of the last entry / 本页最后一个条目的名称
+	HasMore bool                 // Whether there are more pages / 是否还有更多分页
+}
+
 // DirUsage represents a file or directory Usage
 // 表示文件或目录的使用情况
 type DirUsage struct {
@@ -56,3 +84,30 @@ type DirUsage struct {
 // ProgressFunc used as callback for upload or download
 // 上传/下载通用进度回调
 type ProgressFunc func(done int64, total int64)
+
+// StatusError is returned when SeaweedFS answers with an unexpected HTTP status.
+// 当 SeaweedFS 返回非预期的 HTTP 状态码时返回该错误.
+type StatusError struct {
+	Op     string // Operation name, e.g. "upload" / 操作名称
+	Code   int    // HTTP status code / HTTP 状态码
+	Status string // HTTP status line / HTTP 状态描述
+	Body   string // Response body for diagnostics / 用于诊断的响应体
+}
+
+func (e *StatusError) Error() string {
+	if e.Body == "" {
+		return fmt.Sprintf("%s failed: %s", e.Op, e.Status)
+	}
+	return fmt.Sprintf("%s failed: %s %s", e.Op, e.Status, e.Body)
+}
+
+// StatusCode returns the HTTP status code, which lets retry policies skip non-retryable 4xx errors.
+// 返回 HTTP 状态码, 便于重试策略跳过不可重试的 4xx 错误.
+func (e *StatusError) StatusCode() int {
+	return e.Code
+}
+
+// Is reports 412 responses as ErrPreconditionFailed. 将 412 响应视为 ErrPreconditionFailed.
+func (e *StatusError) Is(target error) bool {
+	return target == ErrPreconditionFailed && e.Code == http.StatusPreconditionFailed
+}
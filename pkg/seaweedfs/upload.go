@@ -8,13 +8,11 @@ import (
 	"context"
 	"fmt"
 	"io"
-	"math/rand/v2"
 	"mime/multipart"
 	"net/http"
 	"net/url"
 	"os"
 	"strconv"
-	"time"
 
 	"github.com/GoFurry/seaweedfs-sdk-go/internal/policy"
 	"github.com/GoFurry/seaweedfs-sdk-go/internal/util"
@@ -31,11 +29,37 @@ func (s *SeaweedFSService) UploadWithOptions(
 	headers map[string]string, // Optional HTTP headers / 可选 HTTP 头
 	progress ProgressFunc, // Callback for progress / 进度回调
 ) error {
+	// Reserve quota up front for in-memory readers, otherwise while the body is read.
+	size := readerLen(r)
+	res, err := s.quotas.begin(ctx, dst, size, replaces(opts))
+	if err != nil {
+		return err
+	}
+	if res != nil && size < 0 {
+		r = &quotaReader{r: r, res: res}
+	}
+
+	err = s.uploadWithOptions(ctx, method, dst, r, opts, headers, progress)
+	res.done(err == nil)
+	return err
+}
+
+// uploadWithOptions performs a single upload request without quota checks. 执行单次上传请求, 不检查配额.
+func (s *SeaweedFSService) uploadWithOptions(
+	ctx context.Context,
+	method UploadMethod,
+	dst string,
+	r io.Reader,
+	opts map[string]string,
+	headers map[string]string,
+	progress ProgressFunc,
+) error {
 
 	// NormalizePath ensures path starts with "/" and has no duplicate slashes.
 	dst = util.NormalizePath(dst)
 
 	// Build request URL with optional query parameters.
+	opts = s.uploadDefaults(opts)
 	u := s.FilerEndpoint + dst
 	if len(opts) > 0 {
 		q := url.Values{}
@@ -56,7 +80,7 @@ func (s *SeaweedFSService) UploadWithOptions(
 		req.Header.Set(k, v)
 	}
 
-	resp, err := s.client.Do(req)
+	resp, err := s.do(req)
 	if err != nil {
 		return err
 	}
@@ -65,7 +89,7 @@ func (s *SeaweedFSService) UploadWithOptions(
 	// Any 4xx/5xx is considered a failure.
 	if resp.StatusCode >= 400 {
 		b, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("upload failed: %s %s", resp.Status, b)
+		return &StatusError{Op: "upload", Code: resp.StatusCode, Status: resp.Status, Body: string(b)}
 	}
 
 	// Callback when finished.
@@ -89,7 +113,7 @@ func (s *SeaweedFSService) UploadLarge(
 	headers map[string]string, // Optional HTTP headers / 可选 HTTP 头
 	largeOpt *UploadLargeOptions, // Options for large upload / 大文件上传选项
 	progress ProgressFunc, // Callback for progress / 进度回调
-) error {
+) (err error) {
 
 	dst = util.NormalizePath(dst)
 
@@ -110,8 +134,20 @@ func (s *SeaweedFSService) UploadLarge(
 		largeOpt.MaxRetry = s.policy.UploadMaxRetry
 	}
 
+	// Quota is reserved once for the whole file, or while reading if the size is unknown;
+	// chunks bypass the per-request check.
+	res, err := s.quotas.begin(ctx, dst, size, replaces(opts))
+	if err != nil {
+		return err
+	}
+	defer func() { res.done(err == nil) }()
+	if res != nil && size <= 0 {
+		r = &quotaReader{r: r, res: res}
+	}
+
 	var uploaded int64
 	buf := make([]byte, chunkSize)
+	restHeaders := withoutPreconditions(headers)
 
 	// Main upload loop: upload until total size is reached.
 	for uploaded < size {
@@ -174,7 +210,13 @@ func (s *SeaweedFSService) UploadLarge(
 			}
 
 			// Upload this chunk.
-			err = s.UploadWithOptions(ctx, method, dst, chunkReader, chunkOpts, headers, progress)
+			// Preconditions apply to the first request only; later chunks would fail them.
+			chunkHeaders := headers
+			if uploaded > 0 {
+				chunkHeaders = restHeaders
+			}
+
+			err = s.uploadWithOptions(ctx, method, dst, chunkReader, chunkOpts, chunkHeaders, progress)
 			if err == nil {
 				lastErr = nil
 				break
@@ -188,16 +230,8 @@ func (s *SeaweedFSService) UploadLarge(
 			lastErr = err
 
 			// Exponential backoff with jitter.
-			sleep := s.policy.BackoffBase * (1 << attempt)
-			if sleep > s.policy.BackoffMax {
-				sleep = s.policy.BackoffMax
-			}
-			sleep = time.Duration(float64(sleep) * (0.5 + rand.Float64()/2))
-
-			select {
-			case <-ctx.Done():
-				return ctx.Err()
-			case <-time.After(sleep):
+			if err := s.backoff(ctx, attempt); err != nil {
+				return err
 			}
 		}
 
@@ -252,17 +286,7 @@ func (s *SeaweedFSService) UploadFileSmart(
 	headers["Content-Type"] = contentType
 
 	// Choose upload strategy based on file size.
-	if fh.Size <= largeThreshold {
-		return s.UploadWithOptions(ctx, method, dst, file, opts, headers, progress)
-	} else {
-		return s.UploadLarge(ctx, method, dst, file, fh.Size, chunkSize, opts, headers,
-			&UploadLargeOptions{
-				MaxRetry:  3,
-				UseOffset: true,
-			},
-			progress,
-		)
-	}
+	return s.UploadReaderSmart(ctx, method, dst, file, fh.Size, largeThreshold, chunkSize, opts, headers, progress)
 }
 
 // UploadReaderSmart uploads data from an io.Reader intelligently.
@@ -289,7 +313,7 @@ func (s *SeaweedFSService) UploadReaderSmart(
 
 	// Choose upload strategy based on size / 根据大小选择上传策略
 	if size <= largeThreshold {
-		return s.UploadWithOptions(ctx, method, dst, r, opts, headers, progress)
+		return s.uploadSmall(ctx, method, dst, r, size, opts, headers, progress)
 	}
 
 	return s.UploadLarge(
@@ -302,6 +326,45 @@ func (s *SeaweedFSService) UploadReaderSmart(
 	)
 }
 
+// uploadSmall uploads data below the large-upload threshold in a single request. The quota is reserved
+// for the known size before the request starts, so an upload that does not fit is rejected up front
+// instead of being cut off mid-stream; compressed data is reserved at its original size.
+// Compressed uploads are streamed in a single request, so only data below the large-upload threshold is
+// compressed; larger data keeps the chunked, retried path.
+// 以单个请求上传低于分片阈值的数据. 配额在请求开始前按已知大小预留, 因此超出配额的上传会被提前拒绝, 而不是在传输中途被中断;
+// 压缩数据按原始大小预留. 压缩上传以单个流式请求发送, 因此只压缩低于分片阈值的数据, 更大的数据仍走带重试的分片上传.
+func (s *SeaweedFSService) uploadSmall(
+	ctx context.Context,
+	method UploadMethod,
+	dst string,
+	r io.Reader,
+	size int64,
+	opts map[string]string,
+	headers map[string]string,
+	progress ProgressFunc,
+) error {
+	// Like UploadLarge, a non-positive size is treated as unknown.
+	if size <= 0 {
+		size = readerLen(r)
+	}
+	res, err := s.quotas.begin(ctx, dst, size, replaces(opts))
+	if err != nil {
+		return err
+	}
+	if res != nil && size < 0 {
+		r = &quotaReader{r: r, res: res}
+	}
+
+	var compress bool
+	if r, compress = s.maybeCompress(r, size, headers); compress {
+		err = s.uploadCompressed(ctx, method, dst, r, size, opts, headers, progress)
+	} else {
+		err = s.uploadWithOptions(ctx, method, dst, r, opts, headers, progress)
+	}
+	res.done(err == nil)
+	return err
+}
+
 // UploadLocalFile uploads a local file from filesystem.
 // 从本地文件系统上传文件.
 func (s *SeaweedFSService) UploadLocalFile(
@@ -0,0 +1,193 @@
+// Package seaweedfs provides a Go client for interacting with SeaweedFS.
+// It includes typed, validated upload options that serialise to filer query parameters and headers.
+// 提供 SeaweedFS 的 Go 客户端, 包括可序列化为 filer 查询参数与请求头的类型化上传选项 (带校验).
+package seaweedfs
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Replication is a SeaweedFS replica placement such as "001": copies on other data centers,
+// other racks and the same rack.
+// SeaweedFS 副本放置策略, 如 "001": 依次为其他数据中心、其他机架及同机架上的副本数.
+type Replication string
+
+// Validate checks that r is empty or three digits. 检查 r 为空或为三位数字.
+func (r Replication) Validate() error {
+	if r == "" {
+		return nil
+	}
+	if len(r) != 3 || strings.Trim(string(r), "0123456789") != "" {
+		return fmt.Errorf("invalid replication %q: want three digits such as \"001\"", string(r))
+	}
+	return nil
+}
+
+// UploadOptions are the typed filer upload parameters. Zero fields are not sent.
+// Use Encode to obtain the query and header maps accepted by UploadWithOptions, UploadLarge and
+// the smart variants, or call Upload directly.
+// 类型化的 filer 上传参数, 零值字段不会发送. 可通过 Encode 得到 UploadWithOptions、UploadLarge
+// 及智能上传函数接受的查询参数与请求头, 或直接调用 Upload.
+type UploadOptions struct {
+	Collection         string        // Target collection / 目标集合
+	Replication        Replication   // Replica placement / 副本放置策略
+	TTL                time.Duration // Time to live, minute granularity / 生存时间 (分钟粒度)
+	DataCenter         string        // Preferred data center / 首选数据中心
+	Rack               string        // Preferred rack / 首选机架
+	DataNode           string        // Preferred data node / 首选数据节点
+	DiskType           string        // Disk type, e.g. "hdd" or "ssd" / 磁盘类型
+	Fsync              bool          // Fsync the volume write / 卷写入时执行 fsync
+	MaxMB              int           // Filer auto-chunk size in MB / filer 自动分块大小 (MB)
+	Mode               os.FileMode   // Permission bits / 权限位
+	SaveInside         bool          // Store small content inside the filer entry / 小文件内容直接存储在 filer 条目中
+	SkipCheckParentDir bool          // Skip the parent directory check / 跳过父目录检查
+	ContentType        string        // Content-Type header / Content-Type 请求头
+	ContentDisposition string        // Content-Disposition header / Content-Disposition 请求头
+	Tags               FileTags      // Tags stored as Seaweed- headers / 以 Seaweed- 请求头存储的标签
+
+	Query  map[string]string // Additional raw query parameters / 额外的原始查询参数
+	Header map[string]string // Additional raw headers / 额外的原始请求头
+
+	LargeThreshold int64 // Client-side: size above which Upload chunks (default 32 MiB) / 客户端: Upload 分片上传阈值 (默认 32 MiB)
+	ChunkSize      int64 // Client-side: chunk size for Upload (default 8 MiB) / 客户端: Upload 分片大小 (默认 8 MiB)
+}
+
+// Validate checks every field before any request is sent. 在发送任何请求前校验所有字段.
+func (o *UploadOptions) Validate() error {
+	if err := o.Replication.Validate(); err != nil {
+		return err
+	}
+	for name, v := range map[string]string{
+		"collection": o.Collection,
+		"disk type":  o.DiskType,
+	} {
+		if strings.Trim(v, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_.-") != "" {
+			return fmt.Errorf("invalid %s %q: only letters, digits, '_', '.' and '-' are allowed", name, v)
+		}
+	}
+	if o.TTL < 0 {
+		return fmt.Errorf("invalid ttl %s: must not be negative", o.TTL)
+	}
+	if o.MaxMB < 0 {
+		return fmt.Errorf("invalid maxMB %d: must not be negative", o.MaxMB)
+	}
+	if o.Mode&^os.ModePerm != 0 {
+		return fmt.Errorf("invalid mode %s: only permission bits are allowed", o.Mode)
+	}
+	if o.ContentType != "" {
+		if _, _, err := mime.ParseMediaType(o.ContentType); err != nil {
+			return fmt.Errorf("invalid content type %q: %w", o.ContentType, err)
+		}
+	}
+	if o.ContentDisposition != "" {
+		if _, _, err := mime.ParseMediaType(o.ContentDisposition); err != nil {
+			return fmt.Errorf("invalid content disposition %q: %w", o.ContentDisposition, err)
+		}
+	}
+	for k, v := range o.Tags {
+		if k == "" || strings.ContainsAny(k, " \t\r\n:") {
+			return fmt.Errorf("invalid tag name %q", k)
+		}
+		if strings.ContainsAny(v, "\r\n") {
+			return fmt.Errorf("invalid value for tag %q: contains a line break", k)
+		}
+	}
+	if o.LargeThreshold < 0 || o.ChunkSize < 0 {
+		return fmt.Errorf("invalid chunking: threshold and chunk size must not be negative")
+	}
+	return nil
+}
+
+// Encode validates o and returns the filer query parameters and HTTP headers it stands for.
+// A nil receiver encodes to empty maps.
+// 校验 o 并返回对应的 filer 查询参数与 HTTP 请求头, nil 接收者返回空 map.
+func (o *UploadOptions) Encode() (query, headers map[string]string, err error) {
+	query = make(map[string]string)
+	headers = make(map[string]string)
+	if o == nil {
+		return query, headers, nil
+	}
+	if err := o.Validate(); err != nil {
+		return nil, nil, err
+	}
+
+	for k, v := range o.Query {
+		query[k] = v
+	}
+	for k, v := range o.Header {
+		headers[k] = v
+	}
+
+	set := func(k, v string) {
+		if v != "" {
+			query[k] = v
+		}
+	}
+	set("collection", o.Collection)
+	set("replication", string(o.Replication))
+	set("ttl", FormatTTL(o.TTL))
+	set("dataCenter", o.DataCenter)
+	set("rack", o.Rack)
+	set("dataNode", o.DataNode)
+	set("disk", o.DiskType)
+	if o.Fsync {
+		query["fsync"] = "true"
+	}
+	if o.MaxMB > 0 {
+		query["maxMB"] = strconv.Itoa(o.MaxMB)
+	}
+	if o.Mode != 0 {
+		query["mode"] = strconv.FormatUint(uint64(o.Mode), 8)
+	}
+	if o.SaveInside {
+		query["saveInside"] = "true"
+	}
+	if o.SkipCheckParentDir {
+		query["skipCheckParentDir"] = "true"
+	}
+
+	if o.ContentType != "" {
+		headers["Content-Type"] = o.ContentType
+	}
+	if o.ContentDisposition != "" {
+		headers["Content-Disposition"] = o.ContentDisposition
+	}
+	for k, v := range o.Tags {
+		headers["Seaweed-"+k] = v
+	}
+	return query, headers, nil
+}
+
+// Upload uploads r to dst with typed options, choosing a normal or chunked upload by size like
+// UploadReaderSmart. Options are validated before any request is sent.
+// 使用类型化选项上传 r 到 dst, 与 UploadReaderSmart 一样按大小选择普通或分片上传, 发送请求前会校验选项.
+func (s *SeaweedFSService) Upload(
+	ctx context.Context,
+	method UploadMethod, // HTTP method / HTTP 方法
+	dst string, // Destination path / 目标路径
+	r io.Reader, // Source reader / 数据源
+	size int64, // Total size / 数据总大小
+	o *UploadOptions, // Typed upload options, may be nil / 类型化上传选项, 可为 nil
+	progress ProgressFunc, // Callback for progress / 进度回调
+) error {
+	query, headers, err := o.Encode()
+	if err != nil {
+		return err
+	}
+
+	largeThreshold, chunkSize := int64(32<<20), int64(8<<20)
+	if o != nil && o.LargeThreshold > 0 {
+		largeThreshold = o.LargeThreshold
+	}
+	if o != nil && o.ChunkSize > 0 {
+		chunkSize = o.ChunkSize
+	}
+	return s.UploadReaderSmart(ctx, method, dst, r, size, largeThreshold, chunkSize, query, headers, progress)
+}
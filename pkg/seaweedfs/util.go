@@ -11,6 +11,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -277,3 +278,65 @@ func TempFileName(prefix, suffix string) string {
 	t := time.Now().UnixNano()
 	return fmt.Sprintf("%s%d%s", prefix, t, suffix)
 }
+
+// ttlUnits lists SeaweedFS TTL units in ascending order, in minutes. SeaweedFS TTL 单位 (按分钟升序).
+var ttlUnits = []struct {
+	minutes int64
+	unit    string
+}{
+	{1, "m"},
+	{60, "h"},
+	{24 * 60, "d"},
+	{7 * 24 * 60, "w"},
+	{30 * 24 * 60, "M"},
+	{365 * 24 * 60, "y"},
+}
+
+// FormatTTL converts a duration into a SeaweedFS TTL string such as "90m", "3d" or "2w".
+// SeaweedFS stores at most 255 of a unit with minute granularity, so durations that cannot be
+// represented exactly are rounded up. Zero or negative durations return "".
+// 将时长转换为 SeaweedFS TTL 字符串 (如 "90m"、"3d"、"2w"). SeaweedFS 以分钟为最小粒度且每个单位最多 255,
+// 无法精确表示的时长会向上取整, 零或负数返回 "".
+func FormatTTL(d time.Duration) string {
+	if d <= 0 {
+		return ""
+	}
+	mins := int64((d + time.Minute - 1) / time.Minute)
+
+	// Prefer the largest unit that represents the duration exactly.
+	for i := len(ttlUnits) - 1; i >= 0; i-- {
+		if u := ttlUnits[i]; mins%u.minutes == 0 && mins/u.minutes <= 255 {
+			return fmt.Sprintf("%d%s", mins/u.minutes, u.unit)
+		}
+	}
+	// Otherwise round up with the smallest unit that fits.
+	for _, u := range ttlUnits {
+		if n := (mins + u.minutes - 1) / u.minutes; n <= 255 {
+			return fmt.Sprintf("%d%s", n, u.unit)
+		}
+	}
+	return "255y"
+}
+
+// ParseTTL parses a SeaweedFS TTL string such as "3m", "4h", "5d", "6w", "7M" or "8y".
+// A number without unit is interpreted as minutes, like SeaweedFS does.
+// 解析 SeaweedFS TTL 字符串 (如 "3m"、"4h"、"5d"、"6w"、"7M"、"8y"), 无单位时按分钟处理, 与 SeaweedFS 一致.
+func ParseTTL(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	num, unit := s, "m"
+	if last := s[len(s)-1:]; last < "0" || last > "9" {
+		num, unit = s[:len(s)-1], last
+	}
+	n, err := strconv.ParseInt(num, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid ttl %q", s)
+	}
+	for _, u := range ttlUnits {
+		if u.unit == unit {
+			return time.Duration(n*u.minutes) * time.Minute, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid ttl unit %q", unit)
+}
@@ -0,0 +1,235 @@
+// Package seaweedfs provides a Go client for interacting with SeaweedFS.
+// It includes object versioning emulated with copies in a hidden .versions directory.
+// 提供 SeaweedFS 的 Go 客户端, 包括通过隐藏 .versions 目录中的副本模拟的对象版本管理.
+package seaweedfs
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/GoFurry/seaweedfs-sdk-go/internal/util"
+)
+
+// VersionsDirName is the hidden sibling directory that holds previous revisions. 保存历史版本的隐藏同级目录名.
+const VersionsDirName = ".versions"
+
+// versionTimeLayout formats the mtime part of a version ID; IDs sort chronologically.
+const versionTimeLayout = "20060102T150405Z"
+
+// VersionRetention limits how many previous revisions are kept. Zero fields are unlimited.
+// A version is pruned when it exceeds KeepLast or is older than MaxAge.
+// 限制保留的历史版本, 零值表示不限制. 超出 KeepLast 或早于 MaxAge 的版本会被清理.
+type VersionRetention struct {
+	KeepLast int           // Keep at most this many versions / 最多保留的版本数
+	MaxAge   time.Duration // Drop versions archived longer ago than this / 归档时间早于该时长的版本会被删除
+}
+
+// FileVersion describes a previous revision of a file. 描述文件的一个历史版本.
+type FileVersion struct {
+	ID         string    // Version ID "<mtime>-<md5>" / 版本 ID
+	Path       string    // Path of the version copy / 版本副本路径
+	Mtime      time.Time // Modification time of the revision / 该版本的修改时间
+	Md5        string    // MD5 of the revision (hex) / 该版本的 MD5 (hex)
+	Size       int64     // Size in bytes / 大小 (字节)
+	ArchivedAt time.Time // When the revision was superseded / 被覆盖 (归档) 的时间
+}
+
+// Versioner writes files while keeping previous revisions in .versions/<name>/<mtime>-<md5>
+// next to them, pruning old revisions according to Retention on every write.
+// 写入文件时将历史版本保存在同级的 .versions/<name>/<mtime>-<md5> 下, 每次写入时按 Retention 清理旧版本.
+type Versioner struct {
+	s              *SeaweedFSService
+	Retention      VersionRetention // Retention applied during writes / 写入时应用的保留策略
+	LargeThreshold int64            // Threshold for chunked upload / 分片上传阈值
+	ChunkSize      int64            // Chunk size for large files / 大文件分片大小
+}
+
+// NewVersioner creates a Versioner with the given retention. 使用给定保留策略创建 Versioner.
+func NewVersioner(s *SeaweedFSService, retention VersionRetention) *Versioner {
+	return &Versioner{
+		s:              s,
+		Retention:      retention,
+		LargeThreshold: 32 << 20,
+		ChunkSize:      8 << 20,
+	}
+}
+
+// versionsDir returns the directory holding the versions of p. 返回保存 p 历史版本的目录.
+func versionsDir(p string) string {
+	p = util.NormalizePath(p)
+	return JoinPath(path.Dir(p), VersionsDirName, path.Base(p))
+}
+
+// Upload snapshots the current content of dst (if any), writes r to dst and prunes old versions.
+// 先为 dst 的当前内容 (若存在) 创建版本快照, 再将 r 写入 dst 并清理旧版本.
+func (v *Versioner) Upload(ctx context.Context, dst string, r io.Reader, size int64, headers map[string]string) error {
+	if _, err := v.Snapshot(ctx, dst); err != nil {
+		return err
+	}
+	if err := v.s.UploadReaderSmart(ctx, UploadMethodPut, dst, r, size,
+		v.LargeThreshold, v.ChunkSize, nil, headers, nil); err != nil {
+		return err
+	}
+	_, err := v.Prune(ctx, dst)
+	return err
+}
+
+// Snapshot copies the current content of p into its versions directory and returns the version ID.
+// It returns "" when p does not exist; an identical existing version is reused.
+// 将 p 的当前内容复制到版本目录并返回版本 ID, p 不存在时返回 "", 已存在相同版本时直接复用.
+func (v *Versioner) Snapshot(ctx context.Context, p string) (string, error) {
+	stat, err := v.s.Stat(ctx, p, false)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if stat.IsDir {
+		return "", fmt.Errorf("snapshot %s: is a directory", p)
+	}
+
+	sum := md5Hex(stat.Md5)
+	if sum == "" {
+		// Chunked uploads may have no whole-file MD5 on the filer.
+		if sum, err = v.hash(ctx, p); err != nil {
+			return "", err
+		}
+	}
+	id := stat.Mtime.UTC().Format(versionTimeLayout) + "-" + sum
+	dst := JoinPath(versionsDir(p), id)
+
+	exists, err := v.s.Exists(ctx, dst)
+	if err != nil {
+		return "", err
+	}
+	if !exists {
+		if err := v.s.Copy(ctx, p, dst); err != nil {
+			return "", err
+		}
+	}
+	return id, nil
+}
+
+// hash computes the MD5 of the stored bytes of p. 计算 p 存储内容的 MD5.
+func (v *Versioner) hash(ctx context.Context, p string) (string, error) {
+	rc, _, _, err := v.s.DownloadWithOptions(ctx, p, nil, nil, nil)
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+	h := md5.New()
+	if _, err := io.Copy(h, rc); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// ListVersions returns the previous revisions of p, newest first. 返回 p 的历史版本, 最新的在前.
+func (v *Versioner) ListVersions(ctx context.Context, p string) ([]FileVersion, error) {
+	dir := versionsDir(p)
+	entries, err := v.s.List(ctx, dir, "", "", nil)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var out []FileVersion
+	for _, e := range entries {
+		ts, sum, ok := strings.Cut(e.Name, "-")
+		if e.IsDir || !ok {
+			continue
+		}
+		mtime, err := time.Parse(versionTimeLayout, ts)
+		if err != nil {
+			continue
+		}
+		out = append(out, FileVersion{
+			ID:         e.Name,
+			Path:       JoinPath(dir, e.Name),
+			Mtime:      mtime,
+			Md5:        sum,
+			Size:       e.Size,
+			ArchivedAt: util.ParseSeaweedTime(e.Mtime),
+		})
+	}
+
+	sort.Slice(out, func(i, j int) bool { return out[i].ID > out[j].ID })
+	return out, nil
+}
+
+// GetVersion opens the revision of p with the given ID. 打开 p 指定 ID 的历史版本.
+func (v *Versioner) GetVersion(ctx context.Context, p, id string) (io.ReadCloser, http.Header, error) {
+	if err := validVersionID(id); err != nil {
+		return nil, nil, err
+	}
+	return v.s.Download(ctx, JoinPath(versionsDir(p), id), nil)
+}
+
+// RestoreVersion makes the revision with the given ID the current content of p.
+// The content being replaced is snapshotted first, so a restore can itself be undone.
+// 将指定 ID 的版本恢复为 p 的当前内容, 被替换的内容会先创建快照, 因此恢复操作本身也可撤销.
+func (v *Versioner) RestoreVersion(ctx context.Context, p, id string) error {
+	if err := validVersionID(id); err != nil {
+		return err
+	}
+	src := JoinPath(versionsDir(p), id)
+	if _, err := v.s.Stat(ctx, src, false); err != nil {
+		return err
+	}
+
+	if _, err := v.Snapshot(ctx, p); err != nil {
+		return err
+	}
+	if err := v.s.Copy(ctx, src, p); err != nil {
+		return err
+	}
+	_, err := v.Prune(ctx, p)
+	return err
+}
+
+// Prune applies Retention to the versions of p and returns the IDs of deleted versions.
+// 对 p 的历史版本应用 Retention, 返回被删除的版本 ID.
+func (v *Versioner) Prune(ctx context.Context, p string) ([]string, error) {
+	if v.Retention.KeepLast <= 0 && v.Retention.MaxAge <= 0 {
+		return nil, nil
+	}
+	versions, err := v.ListVersions(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+
+	cutoff := time.Now().Add(-v.Retention.MaxAge)
+	var pruned []string
+	for i, ver := range versions {
+		tooMany := v.Retention.KeepLast > 0 && i >= v.Retention.KeepLast
+		tooOld := v.Retention.MaxAge > 0 && ver.ArchivedAt.Before(cutoff)
+		if !tooMany && !tooOld {
+			continue
+		}
+		if err := v.s.deleteEntry(ctx, ver.Path, nil); err != nil {
+			return pruned, err
+		}
+		pruned = append(pruned, ver.ID)
+	}
+	return pruned, nil
+}
+
+// validVersionID rejects IDs that would escape the versions directory. 拒绝会跳出版本目录的 ID.
+func validVersionID(id string) error {
+	if id == "" || strings.ContainsAny(id, "/\\") || id == "." || id == ".." {
+		return fmt.Errorf("invalid version id %q", id)
+	}
+	return nil
+}